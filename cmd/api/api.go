@@ -1,6 +1,7 @@
 package main
 
 import (
+	controller2 "base-be-golang/internal/adapter/controller"
 	"base-be-golang/shared/api"
 	"base-be-golang/shared/base"
 	"flag"
@@ -39,6 +40,9 @@ func main() {
 	}
 
 	// BUSINESS MODULE
+	start.Register(func(dbConn *gorm.DB, port base.Port, ctrl base.BaseController) api.Router {
+		return controller2.NewAuditLogController(dbConn, port, ctrl)
+	})
 
 	err = start.Start()
 	if err != nil {
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"base-be-golang/internal/migrations"
+	"base-be-golang/pkg/db"
+	"base-be-golang/pkg/migration"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	iammigrations "github.com/rdhmuhammad/base-be-golang/iam-module/shared/migrations"
+)
+
+const usage = `usage: go run ./cmd/migrate [-env file] [-step n] [-dir dir] <command>
+
+commands:
+  up             apply every pending migration
+  down           revert the last applied migration (-step n to revert n)
+  status         list migrations with applied time
+  create <name>  write an empty migration to -dir (default internal/migrations)
+`
+
+func main() {
+	var (
+		envFile string
+		step    int
+		dir     string
+	)
+	flag.StringVar(&envFile, "env", ".env.stag", "Provide env file path")
+	flag.IntVar(&step, "step", 1, "Number of migration to revert on down")
+	flag.StringVar(&dir, "dir", "internal/migrations", "Migration package directory for create")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flag.Arg(0) == "create" {
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		path, err := migration.Create(dir, flag.Arg(1), time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("created", path)
+		return
+	}
+
+	err := godotenv.Load(envFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dbConn, err := db.Default()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// ========================= REGISTER MIGRATION =========================
+	var sources []migration.Source
+	// IAM MODULE
+	if t, _ := strconv.ParseBool(os.Getenv("IAM_MODULE_OFF")); !t {
+		sources = append(sources, iammigrations.Source())
+	}
+	// BUSINESS MODULE
+	sources = append(sources, migrations.Source())
+
+	migrator, err := migration.NewMigrator(dbConn, sources...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var result []migration.Status
+	switch flag.Arg(0) {
+	case "up":
+		result, err = migrator.Up(ctx)
+	case "down":
+		result, err = migrator.Down(ctx, step)
+	case "status":
+		result, err = migrator.Status(ctx)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	printStatus(flag.Arg(0), result)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printStatus(command string, result []migration.Status) {
+	if len(result) == 0 && command != "status" {
+		fmt.Println("nothing to migrate")
+		return
+	}
+
+	for _, s := range result {
+		var state string
+		switch {
+		case command == "down":
+			state = "reverted"
+		case s.AppliedAt == nil:
+			state = "pending"
+		default:
+			state = s.AppliedAt.Local().Format(time.DateTime)
+		}
+		fmt.Printf("%s  %-5s  %-40s  %s\n", s.Version, s.Module, s.Name, state)
+	}
+}
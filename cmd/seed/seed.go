@@ -0,0 +1,64 @@
+package main
+
+import (
+	"base-be-golang/internal/seeders"
+	"base-be-golang/pkg/db"
+	"base-be-golang/pkg/seed"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	iamseeders "github.com/rdhmuhammad/base-be-golang/iam-module/shared/seeders"
+)
+
+func main() {
+	var (
+		envFile string
+		only    string
+	)
+	flag.StringVar(&envFile, "env", ".env.stag", "Provide env file path")
+	flag.StringVar(&only, "only", "", "Comma separated seeder to run, e.g. iam.master_roles")
+	flag.Parse()
+
+	err := godotenv.Load(envFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dbConn, err := db.Default()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// ========================= REGISTER SEEDER =========================
+	var sources []seed.Source
+	// IAM MODULE
+	if t, _ := strconv.ParseBool(os.Getenv("IAM_MODULE_OFF")); !t {
+		sources = append(sources, iamseeders.Source())
+	}
+	// BUSINESS MODULE
+	sources = append(sources, seeders.Source())
+
+	var names []string
+	if only != "" {
+		names = strings.Split(only, ",")
+	}
+
+	result, err := seed.NewRunner(dbConn, sources...).
+		Run(context.Background(), os.Getenv("ENVIRONMENT"), names...)
+	for _, r := range result {
+		state := "done"
+		if r.Skipped {
+			state = "skipped (" + r.Reason + ")"
+		}
+		fmt.Printf("%-5s  %-30s  %s\n", r.Module, r.Name, state)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
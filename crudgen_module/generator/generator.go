@@ -0,0 +1,312 @@
+package generator
+
+import (
+	"base-be-golang/pkg/migration"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionSkip   = "skip"
+)
+
+// File one generated or patched file, Path is relative to the project root
+type File struct {
+	Path    string
+	Action  string
+	Content []byte
+}
+
+type fieldView struct {
+	Field
+	GoName   string
+	GoType   string
+	JSON     string
+	DBType   string
+	Validate string
+	Index    bool
+}
+
+type view struct {
+	Spec
+	Package       string
+	Var           string
+	Label         string
+	Param         string
+	Fields        []fieldView
+	Search        []string
+	HasTime       bool
+	Version       string
+	ControllerPkg string
+}
+
+type Generator struct {
+	root  string
+	now   time.Time
+	force bool
+}
+
+// NewGenerator root is the base-be-golang project directory, force overwrite file that already exist
+func NewGenerator(root string, now time.Time, force bool) Generator {
+	return Generator{root: root, now: now, force: force}
+}
+
+/*
+Plan render every file for spec without touching the disk:
+domain, dto, usecase, controller, response message constant, migration,
+localization keys on resource/message and the controller registration in cmd/api.
+*/
+func (g Generator) Plan(spec Spec) ([]File, error) {
+	if err := spec.normalize(); err != nil {
+		return nil, err
+	}
+	v := newView(spec, g.now)
+
+	var files []File
+	if _, err := os.Stat(filepath.Join(g.root, "internal/core/domain/baseentity.go")); os.IsNotExist(err) {
+		file, err := g.render("baseentity", "internal/core/domain/baseentity.go", v)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	sources := []struct{ name, path string }{
+		{"domain", fmt.Sprintf("internal/core/domain/%s.go", snake(v.Entity))},
+		{"dto", fmt.Sprintf("internal/core/usecase/%s/dto.go", v.Package)},
+		{"usecase", fmt.Sprintf("internal/core/usecase/%s/usecase.go", v.Package)},
+		{"controller", fmt.Sprintf("internal/adapter/controller/%s.go", strings.ReplaceAll(snake(v.Entity), "_", "-"))},
+		{"constant", fmt.Sprintf("internal/constant/%s.go", snake(v.Entity))},
+	}
+	for _, src := range sources {
+		file, err := g.render(src.name, src.path, v)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	// one create migration per table, a rerun must not add a second one
+	existing, _ := filepath.Glob(filepath.Join(g.root, "internal/migrations", "*_create_"+v.Table+".go"))
+	if len(existing) > 0 {
+		rel, _ := filepath.Rel(g.root, existing[0])
+		files = append(files, File{Path: rel, Action: ActionSkip})
+	} else {
+		file, err := g.render("migration", fmt.Sprintf("internal/migrations/%s_create_%s.go", v.Version, v.Table), v)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	for _, lang := range []string{"en", "id"} {
+		file, err := g.messages(lang, v)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	file, err := g.register(v)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, file)
+
+	return files, nil
+}
+
+// Write save planned files, skipped file is left untouched
+func (g Generator) Write(files []File) error {
+	for _, f := range files {
+		if f.Action == ActionSkip {
+			continue
+		}
+		path := filepath.Join(g.root, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g Generator) render(name string, path string, v view) (File, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, v); err != nil {
+		return File{}, err
+	}
+
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return File{}, fmt.Errorf("format %s: %w", path, err)
+	}
+
+	action := ActionCreate
+	if _, err := os.Stat(filepath.Join(g.root, path)); err == nil {
+		action = ActionSkip
+		if g.force {
+			action = ActionUpdate
+		}
+	}
+	return File{Path: path, Action: action, Content: content}, nil
+}
+
+/*
+messages add the response message keys to resource/message/<lang>.json,
+key that is already translated is kept
+*/
+func (g Generator) messages(lang string, v view) (File, error) {
+	path := filepath.Join("resource/message", lang+".json")
+	raw, err := os.ReadFile(filepath.Join(g.root, path))
+	if err != nil && !os.IsNotExist(err) {
+		return File{}, err
+	}
+
+	var messages = map[string]string{}
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return File{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var added bool
+	for key, text := range messageTexts(lang, v) {
+		if _, ok := messages[key]; !ok {
+			messages[key] = text
+			added = true
+		}
+	}
+	if !added {
+		return File{Path: path, Action: ActionSkip}, nil
+	}
+
+	content, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: path, Action: ActionUpdate, Content: append(content, '\n')}, nil
+}
+
+func messageTexts(lang string, v view) map[string]string {
+	label := strings.ToUpper(v.Label[:1]) + v.Label[1:]
+	if lang == "id" {
+		return map[string]string{
+			"Create" + v.Entity:    label + " berhasil dibuat",
+			"Update" + v.Entity:    label + " berhasil diperbarui",
+			"Delete" + v.Entity:    label + " berhasil dihapus",
+			"GetDetail" + v.Entity: "Berhasil mengambil detail " + v.Label,
+			"GetList" + v.Entity:   "Berhasil mengambil daftar " + v.Label,
+			v.Entity + "NotFound":  label + " tidak ditemukan",
+		}
+	}
+	return map[string]string{
+		"Create" + v.Entity:    label + " created successfully",
+		"Update" + v.Entity:    label + " updated successfully",
+		"Delete" + v.Entity:    label + " deleted successfully",
+		"GetDetail" + v.Entity: "Get " + v.Label + " detail successfully",
+		"GetList" + v.Entity:   "Get " + v.Label + " list successfully",
+		v.Entity + "NotFound":  label + " not found",
+	}
+}
+
+var controllerImport = regexp.MustCompile(`(?m)^\s*(\w+\s+)?"base-be-golang/internal/adapter/controller"\s*$`)
+
+// register add the controller to the BUSINESS MODULE section of cmd/api, before start.Start
+func (g Generator) register(v view) (File, error) {
+	path := "cmd/api/api.go"
+	raw, err := os.ReadFile(filepath.Join(g.root, path))
+	if err != nil {
+		return File{}, err
+	}
+	source := string(raw)
+
+	match := controllerImport.FindStringSubmatch(source)
+	if match == nil {
+		return File{}, fmt.Errorf("%s does not import base-be-golang/internal/adapter/controller, register New%sController by hand", path, v.Entity)
+	}
+	v.ControllerPkg = "controller"
+	if alias := strings.TrimSpace(match[1]); alias != "" {
+		v.ControllerPkg = alias
+	}
+
+	if strings.Contains(source, v.ControllerPkg+".New"+v.Entity+"Controller(") {
+		return File{Path: path, Action: ActionSkip}, nil
+	}
+
+	anchor := "\n\terr = start.Start()"
+	at := strings.Index(source, anchor)
+	if at < 0 {
+		return File{}, fmt.Errorf("start.Start() not found in %s, register New%sController by hand", path, v.Entity)
+	}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "register", v); err != nil {
+		return File{}, err
+	}
+	source = source[:at] + strings.Trim(buf.String(), "\n") + "\n" + source[at:]
+
+	content, err := format.Source([]byte(source))
+	if err != nil {
+		return File{}, fmt.Errorf("format %s: %w", path, err)
+	}
+	return File{Path: path, Action: ActionUpdate, Content: content}, nil
+}
+
+func newView(spec Spec, now time.Time) view {
+	name := snake(spec.Entity)
+	v := view{
+		Spec:    spec,
+		Package: strings.ReplaceAll(name, "_", ""),
+		Var:     camel(name),
+		Label:   strings.ReplaceAll(name, "_", " "),
+		Param:   camel(name) + "Id",
+		Version: now.UTC().Format(migration.VersionFormat),
+	}
+	if v.Role == "" {
+		v.Role = "constant.RoleIsAdmin"
+	} else {
+		v.Role = fmt.Sprintf("%q", v.Role)
+	}
+
+	for _, f := range spec.Fields {
+		t := fieldTypes[f.Type]
+		fv := fieldView{
+			Field:  f,
+			GoName: pascal(f.Name),
+			GoType: t.goType,
+			JSON:   camel(f.Name),
+			DBType: t.dbType(f.Size),
+			Index:  f.Type == "uint" && strings.HasSuffix(f.Name, "_id"),
+		}
+
+		var rules []string
+		if f.Required && f.Type != "bool" {
+			rules = append(rules, "required")
+		}
+		if f.Type == "string" {
+			rules = append(rules, fmt.Sprintf("max=%d", f.Size))
+		}
+		fv.Validate = strings.Join(rules, ",")
+
+		if f.Searchable {
+			v.Search = append(v.Search, spec.Table+"."+f.Name)
+		}
+		if t.goType == "time.Time" {
+			v.HasTime = true
+		}
+		v.Fields = append(v.Fields, fv)
+	}
+	return v
+}
@@ -0,0 +1,250 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+Spec entity to scaffold, read from JSON (see ParseSpecFile) or from the -fields flag (see ParseFields).
+Only Entity and Fields are required, the rest is derived from Entity.
+
+	{
+	  "entity": "Article",
+	  "softDelete": true,
+	  "versioned": true,
+	  "fields": [
+	    {"name": "title", "type": "string", "size": 150, "required": true, "searchable": true},
+	    {"name": "body", "type": "text"},
+	    {"name": "published_at", "type": "time"}
+	  ]
+	}
+*/
+type Spec struct {
+	Entity     string  `json:"entity"`
+	Table      string  `json:"table"`
+	Route      string  `json:"route"`
+	Role       string  `json:"role"`
+	SoftDelete bool    `json:"softDelete"`
+	Versioned  bool    `json:"versioned"`
+	Fields     []Field `json:"fields"`
+}
+
+type Field struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Size       int    `json:"size"`
+	Required   bool   `json:"required"`
+	Searchable bool   `json:"searchable"`
+}
+
+type fieldType struct {
+	goType string
+	dbType func(size int) string
+}
+
+// fieldTypes supported Field.Type, db type is left to gorm when empty so the migration stay portable
+var fieldTypes = map[string]fieldType{
+	"string":  {goType: "string", dbType: func(size int) string { return fmt.Sprintf("varchar(%d)", size) }},
+	"text":    {goType: "string", dbType: func(int) string { return "text" }},
+	"int":     {goType: "int", dbType: func(int) string { return "" }},
+	"int64":   {goType: "int64", dbType: func(int) string { return "" }},
+	"uint":    {goType: "uint", dbType: func(int) string { return "" }},
+	"float":   {goType: "float64", dbType: func(int) string { return "" }},
+	"decimal": {goType: "float64", dbType: func(int) string { return "decimal(18,2)" }},
+	"bool":    {goType: "bool", dbType: func(int) string { return "" }},
+	"time":    {goType: "time.Time", dbType: func(int) string { return "" }},
+}
+
+var (
+	identPattern    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	reservedColumns = []string{"id", "created_at", "updated_at", "created_by", "updated_by", "deleted_at", "deleted_by", "version"}
+)
+
+func ParseSpecFile(path string) (Spec, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	var spec Spec
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return Spec{}, fmt.Errorf("spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+/*
+ParseFields parse "name:type[:size][:required][:search]" separated by comma,
+e.g. "title:string:150:required:search,body:text,published_at:time"
+*/
+func ParseFields(value string) ([]Field, error) {
+	var fields []Field
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("field %q must be name:type", item)
+		}
+
+		field := Field{Name: parts[0], Type: parts[1]}
+		for _, opt := range parts[2:] {
+			switch opt {
+			case "required":
+				field.Required = true
+			case "search":
+				field.Searchable = true
+			default:
+				size, err := strconv.Atoi(opt)
+				if err != nil {
+					return nil, fmt.Errorf("field %q has unknown option %q", item, opt)
+				}
+				field.Size = size
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// normalize validate spec and fill the derived values
+func (s *Spec) normalize() error {
+	if !identPattern.MatchString(s.Entity) {
+		return fmt.Errorf("entity %q must be an identifier, e.g. Article", s.Entity)
+	}
+	s.Entity = pascal(s.Entity)
+	if token.IsKeyword(strings.ReplaceAll(snake(s.Entity), "_", "")) {
+		return fmt.Errorf("entity %s is a Go keyword, it can not be a package name", s.Entity)
+	}
+
+	if s.Table == "" {
+		s.Table = plural(snake(s.Entity))
+	}
+	if s.Route == "" {
+		s.Route = "/" + strings.ReplaceAll(plural(snake(s.Entity)), "_", "-")
+	}
+	if !strings.HasPrefix(s.Route, "/") {
+		s.Route = "/" + s.Route
+	}
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("entity %s needs at least one field", s.Entity)
+	}
+
+	var names = map[string]bool{}
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		if !identPattern.MatchString(f.Name) {
+			return fmt.Errorf("field %q must be an identifier", f.Name)
+		}
+		f.Name = snake(f.Name)
+		if names[f.Name] {
+			return fmt.Errorf("field %s is declared twice", f.Name)
+		}
+		for _, col := range reservedColumns {
+			if f.Name == col {
+				return fmt.Errorf("field %s is generated already, remove it from the spec", f.Name)
+			}
+		}
+		names[f.Name] = true
+
+		if f.Type == "" {
+			f.Type = "string"
+		}
+		if _, ok := fieldTypes[f.Type]; !ok {
+			return fmt.Errorf("field %s has unsupported type %q", f.Name, f.Type)
+		}
+		if f.Type == "string" && f.Size == 0 {
+			f.Size = 255
+		}
+		if f.Searchable && f.Type != "string" && f.Type != "text" {
+			return fmt.Errorf("field %s is not a text field, it can not be searchable", f.Name)
+		}
+	}
+
+	// search on the first string field when nothing is marked
+	if !s.hasSearch() {
+		for i := range s.Fields {
+			if s.Fields[i].Type == "string" {
+				s.Fields[i].Searchable = true
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s Spec) hasSearch() bool {
+	for _, f := range s.Fields {
+		if f.Searchable {
+			return true
+		}
+	}
+	return false
+}
+
+// ===================== NAMING ===========================
+
+// snake ArticleCategory, articleCategory -> article_category
+func snake(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// pascal article_category, articleCategory -> ArticleCategory, "id" part is upper cased (user_id -> UserID)
+func pascal(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snake(s), "_") {
+		if part == "" {
+			continue
+		}
+		if part == "id" {
+			b.WriteString("ID")
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// camel article_category -> articleCategory, used for json tag
+func camel(s string) string {
+	p := pascal(s)
+	if strings.HasPrefix(p, "ID") {
+		return "id" + p[2:]
+	}
+	return strings.ToLower(p[:1]) + p[1:]
+}
+
+// plural english plural of the last word, enough for table and route name
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
@@ -0,0 +1,379 @@
+package generator
+
+import "text/template"
+
+var templates = template.Must(template.New("crudgen").Parse(`
+{{define "baseentity"}}package domain
+
+import "time"
+
+type BaseEntity struct {
+	ID        uint      ` + "`" + `gorm:"primaryKey;autoIncrement" json:"id"` + "`" + `
+	CreatedAt time.Time ` + "`" + `gorm:"column:created_at;autoCreateTime:false" json:"createdAt"` + "`" + `
+	UpdatedAt time.Time ` + "`" + `gorm:"column:updated_at;autoUpdateTime:false" audit:"-" json:"updatedAt"` + "`" + `
+	CreatedBy string    ` + "`" + `gorm:"column:created_by;type:varchar(100)" json:"createdBy"` + "`" + `
+	UpdatedBy string    ` + "`" + `gorm:"column:updated_by;type:varchar(100)" audit:"-" json:"updatedBy"` + "`" + `
+}
+
+func (receiver *BaseEntity) SetCreated(actor string) {
+	if actor != "" {
+		receiver.CreatedBy = actor
+		receiver.UpdatedBy = actor
+	}
+	receiver.CreatedAt = time.Now().UTC()
+	receiver.UpdatedAt = time.Now().UTC()
+}
+
+func (receiver *BaseEntity) SetUpdated(actor string) {
+	if actor != "" {
+		receiver.UpdatedBy = actor
+	}
+	receiver.UpdatedAt = time.Now().UTC()
+}
+{{end}}
+
+{{define "domain"}}package domain
+{{if or .SoftDelete .Versioned .HasTime}}
+import (
+{{- if or .SoftDelete .Versioned}}
+	"base-be-golang/pkg/db"
+{{- end}}
+{{- if .HasTime}}
+	"time"
+{{- end}}
+)
+{{end}}
+type {{.Entity}} struct {
+	BaseEntity
+{{- if .SoftDelete}}
+	db.SoftDelete
+{{- end}}
+{{- if .Versioned}}
+	db.OptimisticLock
+{{- end}}
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} ` + "`" + `gorm:"column:{{.Name}}" json:"{{.JSON}}"` + "`" + `
+{{- end}}
+}
+
+func (receiver {{.Entity}}) AuditEntity() string {
+	return receiver.TableName()
+}
+
+func (receiver {{.Entity}}) TableName() string {
+	return "{{.Table}}"
+}
+{{end}}
+
+{{define "dto"}}package {{.Package}}
+
+import (
+	"base-be-golang/shared/payload"
+{{- if .HasTime}}
+	"time"
+{{- end}}
+)
+
+type Upsert{{.Entity}}Request struct {
+	ID uint ` + "`" + `json:"-"` + "`" + `
+{{- if .Versioned}}
+	Version uint ` + "`" + `json:"version"` + "`" + `
+{{- end}}
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} ` + "`" + `json:"{{.JSON}}"{{if .Validate}} validate:"{{.Validate}}"{{end}}` + "`" + `
+{{- end}}
+}
+
+type GetList{{.Entity}}Query struct {
+	Filter *payload.GetListQueryNoPeriod ` + "`" + `bindQuery:"dive=true" json:"filter"` + "`" + `
+}
+{{end}}
+
+{{define "usecase"}}package {{.Package}}
+
+import (
+	"base-be-golang/internal/constant"
+	"base-be-golang/internal/core/domain"
+	"base-be-golang/pkg/db"
+	"base-be-golang/pkg/localerror"
+	"base-be-golang/shared/base"
+	"base-be-golang/shared/payload"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Usecase struct {
+	base.Port
+	{{.Var}}Repo db.GenericRepository[domain.{{.Entity}}]
+}
+
+func NewUsecase(dbConn *gorm.DB, port base.Port) Usecase {
+	return Usecase{
+		Port:    port,
+		{{.Var}}Repo: db.NewGenericeRepo(dbConn, domain.{{.Entity}}{}),
+	}
+}
+
+func (u Usecase) Create(ctx context.Context, request Upsert{{.Entity}}Request) error {
+	{{.Var}} := domain.{{.Entity}}{
+{{- range .Fields}}
+		{{.GoName}}: request.{{.GoName}},
+{{- end}}
+	}
+
+	_, err := u.{{.Var}}Repo.Store(ctx, {{.Var}})
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+func (u Usecase) Update(ctx context.Context, request Upsert{{.Entity}}Request) error {
+	{{.Var}}, err := u.{{.Var}}Repo.FindOneByID(ctx, request.ID)
+	if err != nil {
+		err = localerror.NotFound(err, constant.{{.Entity}}NotFound)
+		return u.ErrHandler.ErrorReturn(err)
+	}
+{{- if .Versioned}}
+
+	// version the client read, stale version is rejected on update
+	{{.Var}}.SetVersion(request.Version)
+{{- end}}
+{{range .Fields}}
+	{{$.Var}}.{{.GoName}} = request.{{.GoName}}
+{{- end}}
+
+	err = u.{{.Var}}Repo.UpdateSelectedCols(ctx, {{.Var}}{{range .Fields}}, "{{.Name}}"{{end}})
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+func (u Usecase) Delete(ctx context.Context, id uint) error {
+	_, err := u.{{.Var}}Repo.FindOneByID(ctx, id)
+	if err != nil {
+		err = localerror.NotFound(err, constant.{{.Entity}}NotFound)
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	err = u.{{.Var}}Repo.DeleteByID(ctx, id)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+func (u Usecase) GetDetail(ctx context.Context, id uint) (domain.{{.Entity}}, error) {
+	{{.Var}}, err := u.{{.Var}}Repo.FindOneByID(ctx, id)
+	if err != nil {
+		err = localerror.NotFound(err, constant.{{.Entity}}NotFound)
+		return domain.{{.Entity}}{}, u.ErrHandler.ErrorReturn(err)
+	}
+
+	return {{.Var}}, nil
+}
+
+func (u Usecase) GetList(ctx context.Context, query GetList{{.Entity}}Query) (payload.PaginationResponse[domain.{{.Entity}}], error) {
+	conditions := db.Query(
+		db.When(query.Filter.Search != "", db.Search(query.Filter.Search{{range .Search}}, "{{.}}"{{end}})),
+	)
+
+	result, total, err := u.{{.Var}}Repo.FindPagedByExpression(
+		ctx,
+		conditions,
+		db.PaginationQuery{
+			PerPage: query.Filter.PerPage,
+			Page:    query.Filter.Page,
+		},
+		db.OrderDesc("{{.Table}}.id"),
+	)
+	if err != nil {
+		return payload.PaginationResponse[domain.{{.Entity}}]{}, u.ErrHandler.ErrorReturn(err)
+	}
+
+	return payload.NewPagination(result, total, query.Filter.PerPage, query.Filter.Page), nil
+}
+{{end}}
+
+{{define "controller"}}package controller
+
+import (
+	"base-be-golang/internal/constant"
+	"base-be-golang/internal/core/domain"
+	"base-be-golang/internal/core/usecase/{{.Package}}"
+	"base-be-golang/shared/base"
+	"base-be-golang/shared/payload"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type {{.Entity}}Controller struct {
+	base.BaseController
+	uc {{.Entity}}Usecase
+}
+
+type {{.Entity}}Usecase interface {
+	Create(ctx context.Context, request {{.Package}}.Upsert{{.Entity}}Request) error
+	Update(ctx context.Context, request {{.Package}}.Upsert{{.Entity}}Request) error
+	Delete(ctx context.Context, id uint) error
+	GetDetail(ctx context.Context, id uint) (domain.{{.Entity}}, error)
+	GetList(ctx context.Context, query {{.Package}}.GetList{{.Entity}}Query) (payload.PaginationResponse[domain.{{.Entity}}], error)
+}
+
+func New{{.Entity}}Controller(dbConn *gorm.DB, port base.Port, controller base.BaseController) {{.Entity}}Controller {
+	return {{.Entity}}Controller{
+		BaseController: controller,
+		uc:             {{.Package}}.NewUsecase(dbConn, port),
+	}
+}
+
+func (ctrl {{.Entity}}Controller) Create{{.Entity}}(c *gin.Context) {
+	var request {{.Package}}.Upsert{{.Entity}}Request
+	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	err := ctrl.uc.Create(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.Create{{.Entity}}), err)
+}
+
+func (ctrl {{.Entity}}Controller) Update{{.Entity}}(c *gin.Context) {
+	var request {{.Package}}.Upsert{{.Entity}}Request
+	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("{{.Param}}"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage(err.Error()))
+		return
+	}
+
+	request.ID = uint(id)
+	err = ctrl.uc.Update(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.Update{{.Entity}}), err)
+}
+
+func (ctrl {{.Entity}}Controller) Delete{{.Entity}}(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("{{.Param}}"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage(err.Error()))
+		return
+	}
+
+	err = ctrl.uc.Delete(c.Request.Context(), uint(id))
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.Delete{{.Entity}}), err)
+}
+
+func (ctrl {{.Entity}}Controller) GetDetail{{.Entity}}(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("{{.Param}}"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage(err.Error()))
+		return
+	}
+
+	result, err := ctrl.uc.GetDetail(c.Request.Context(), uint(id))
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant.GetDetail{{.Entity}}), err)
+}
+
+func (ctrl {{.Entity}}Controller) GetList{{.Entity}}(c *gin.Context) {
+	var request = {{.Package}}.GetList{{.Entity}}Query{
+		Filter: &payload.GetListQueryNoPeriod{},
+	}
+	if errs := ctrl.Enigma.BindQueryToFilterAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	request.Filter.SetIfEmpty()
+	result, err := ctrl.uc.GetList(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant.GetList{{.Entity}}), err)
+}
+
+func (ctrl {{.Entity}}Controller) Route(router *gin.RouterGroup) {
+	{{.Var}}Router := router.Group("{{.Route}}",
+		ctrl.Security.Validate(),
+		ctrl.Security.Authorize({{.Role}}),
+	)
+
+	{{.Var}}Router.GET("", ctrl.GetList{{.Entity}})
+	{{.Var}}Router.POST("", ctrl.Create{{.Entity}})
+	{{.Var}}Router.GET("/:{{.Param}}", ctrl.GetDetail{{.Entity}})
+	{{.Var}}Router.PUT("/:{{.Param}}", ctrl.Update{{.Entity}})
+	{{.Var}}Router.DELETE("/:{{.Param}}", ctrl.Delete{{.Entity}})
+}
+{{end}}
+
+{{define "constant"}}package constant
+
+//	Response message {{.Label}}
+const (
+	Create{{.Entity}}    = "Create{{.Entity}}"
+	Update{{.Entity}}    = "Update{{.Entity}}"
+	Delete{{.Entity}}    = "Delete{{.Entity}}"
+	GetDetail{{.Entity}} = "GetDetail{{.Entity}}"
+	GetList{{.Entity}}   = "GetList{{.Entity}}"
+	{{.Entity}}NotFound  = "{{.Entity}}NotFound"
+)
+{{end}}
+
+{{define "migration"}}package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+{{- if .SoftDelete}}
+	"database/sql"
+{{- end}}
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(migration.Migration{
+		Version: "{{.Version}}",
+		Name:    "create_{{.Table}}",
+		Up: func(tx *gorm.DB) error {
+			type {{.Var}} struct {
+				ID        uint      ` + "`" + `gorm:"primaryKey;autoIncrement"` + "`" + `
+				CreatedAt time.Time ` + "`" + `gorm:"column:created_at"` + "`" + `
+				UpdatedAt time.Time ` + "`" + `gorm:"column:updated_at"` + "`" + `
+				CreatedBy string    ` + "`" + `gorm:"column:created_by;type:varchar(100)"` + "`" + `
+				UpdatedBy string    ` + "`" + `gorm:"column:updated_by;type:varchar(100)"` + "`" + `
+{{- if .SoftDelete}}
+				DeletedAt sql.NullTime ` + "`" + `gorm:"column:deleted_at;index:idx_{{.Table}}_deleted_at"` + "`" + `
+				DeletedBy string       ` + "`" + `gorm:"column:deleted_by;type:varchar(100)"` + "`" + `
+{{- end}}
+{{- if .Versioned}}
+				Version uint ` + "`" + `gorm:"column:version;not null;default:1"` + "`" + `
+{{- end}}
+{{- range .Fields}}
+				{{.GoName}} {{.GoType}} ` + "`" + `gorm:"column:{{.Name}}{{if .DBType}};type:{{.DBType}}{{end}}{{if .Index}};index:idx_{{$.Table}}_{{.Name}}{{end}}"` + "`" + `
+{{- end}}
+			}
+			return tx.Table("{{.Table}}").Migrator().CreateTable(&{{.Var}}{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("{{.Table}}")
+		},
+	})
+}
+{{end}}
+
+{{define "register"}}
+	start.Register(func(dbConn *gorm.DB, port base.Port, ctrl base.BaseController) api.Router {
+		return {{.ControllerPkg}}.New{{.Entity}}Controller(dbConn, port, ctrl)
+	})
+{{end}}
+`))
@@ -0,0 +1,3 @@
+module github.com/rdhmuhammad/base-be-golang/crudgen-module
+
+go 1.23.10
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rdhmuhammad/base-be-golang/crudgen-module/generator"
+)
+
+const usage = `usage: go run ./crudgen_module [flags]
+
+scaffold domain, dto, usecase, controller, migration and response messages of one entity
+into the business module, then register the controller in cmd/api.
+
+  go run ./crudgen_module -entity Article -fields "title:string:150:required:search,body:text,published_at:time"
+  go run ./crudgen_module -spec article.json
+
+field is name:type[:size][:required][:search], type is one of
+string, text, int, int64, uint, float, decimal, bool, time
+
+flags:
+`
+
+func main() {
+	var (
+		specFile   string
+		entity     string
+		fields     string
+		table      string
+		route      string
+		role       string
+		softDelete bool
+		versioned  bool
+		root       string
+		dryRun     bool
+		force      bool
+	)
+	flag.StringVar(&specFile, "spec", "", "Entity spec JSON file, other entity flags are ignored")
+	flag.StringVar(&entity, "entity", "", "Entity name in PascalCase, e.g. Article")
+	flag.StringVar(&fields, "fields", "", "Comma separated fields")
+	flag.StringVar(&table, "table", "", "Table name (default snake case plural of entity)")
+	flag.StringVar(&route, "route", "", "Route group (default kebab case plural of entity)")
+	flag.StringVar(&role, "role", "", "Role allowed to access the routes (default ADMIN)")
+	flag.BoolVar(&softDelete, "soft-delete", false, "Embed db.SoftDelete")
+	flag.BoolVar(&versioned, "versioned", false, "Embed db.OptimisticLock")
+	flag.StringVar(&root, "root", ".", "Project root directory")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the plan without writing")
+	flag.BoolVar(&force, "force", false, "Overwrite generated files that already exist")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var (
+		spec generator.Spec
+		err  error
+	)
+	switch {
+	case specFile != "":
+		spec, err = generator.ParseSpecFile(specFile)
+	case entity != "":
+		spec = generator.Spec{
+			Entity:     entity,
+			Table:      table,
+			Route:      route,
+			Role:       role,
+			SoftDelete: softDelete,
+			Versioned:  versioned,
+		}
+		spec.Fields, err = generator.ParseFields(fields)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gen := generator.NewGenerator(root, time.Now(), force)
+	files, err := gen.Plan(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range files {
+		fmt.Printf("%-7s %s\n", f.Action, f.Path)
+	}
+	if dryRun {
+		return
+	}
+
+	if err := gen.Write(files); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("\nrun `go run ./cmd/migrate up` to create the table")
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"base-be-golang/pkg/db"
+	"base-be-golang/pkg/search"
 	"base-be-golang/shared/payload"
 	"context"
 	"fmt"
@@ -8,30 +10,74 @@ import (
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/constant"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepo struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search search.Engine
 }
 
 type UserListQuery struct {
 	Filter    *payload.GetListQueryNoPeriod `bindQuery:"dive=true" json:"filter"`
 	RoleName  string                        `json:"roleName"`
 	StatusKey string                        `json:"statusKey"`
+	Query     db.Filter                     `bindQuery:"ignore=true" json:"-"`
+}
+
+// UserListFilter filter[...] and sort accepted by UserDashboardList, column is of the union result
+var UserListFilter = db.FilterSpec{
+	Fields: map[string]db.FilterField{
+		"status":     {Column: "status", Operators: []string{db.FilterEq, db.FilterIn}},
+		"roleName":   {Column: "role_name", Operators: []string{db.FilterEq, db.FilterIn, db.FilterNe}},
+		"name":       {Column: "name", Operators: []string{db.FilterEq, db.FilterLike}},
+		"email":      {Column: "email", Operators: []string{db.FilterEq, db.FilterLike}},
+		"lastActive": {Column: "last_active", Type: db.FilterTime, Operators: []string{db.FilterGte, db.FilterLte, db.FilterNull}},
+	},
+	Sort: map[string]string{
+		"name":       "name",
+		"email":      "email",
+		"lastActive": "last_active",
+	},
+	DefaultSort: db.Order(db.OrderDesc("last_active")),
 }
 
 func NewUserRepo(db *gorm.DB) UserRepo {
-	return userRepo{db: db}
+	return userRepo{db: db, search: search.From(db)}
 }
 
 type UserRepo interface {
 	UserDashboardList(ctx context.Context, query UserListQuery) ([]domain.UserListItem, int, int, error)
 }
 
+/*
+UserDashboardList search full_name and email through the search engine, a searched list is ordered
+by relevance first and the sort of the filter break the tie. Total counts every hit; with the local engine
+only the 1000 best hits of each table are ranked, the rest follow in the sort of the filter.
+*/
 func (repo userRepo) UserDashboardList(ctx context.Context, query UserListQuery) ([]domain.UserListItem, int, int, error) {
-	db := repo.db.WithContext(ctx)
+	conn := db.Conn(ctx, repo.db)
+
+	// relevance 0 without search, both side of the union need the column
+	var (
+		userMatch  = search.Match{Relevance: clause.Expr{SQL: "0"}}
+		adminMatch = search.Match{Relevance: clause.Expr{SQL: "0"}}
+		order      = query.Query
+	)
+	if query.Filter.Search != "" {
+		var err error
+		userMatch, err = repo.search.Match(ctx, domain.User{}, query.Filter.Search)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to search users: %w", err)
+		}
+		adminMatch, err = repo.search.Match(ctx, domain.UserAdmin{}, query.Filter.Search)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to search user admins: %w", err)
+		}
+		order.Order = append(db.Order(db.OrderDesc("relevance")), order.Order...)
+	}
 
-	buildConditions := func(baseQuery *gorm.DB, tableName string, statusCol string) *gorm.DB {
+	buildConditions := func(baseQuery *gorm.DB, tableName string, statusCol string, match search.Match) *gorm.DB {
 		var status = 0
 		// Apply status filter
 		if query.StatusKey != "" {
@@ -41,11 +87,8 @@ func (repo userRepo) UserDashboardList(ctx context.Context, query UserListQuery)
 			baseQuery = baseQuery.Where(fmt.Sprintf("%s.%s = ?", tableName, statusCol), status)
 		}
 		// Apply search filter
-		if query.Filter.Search != "" {
-			searchPattern := "%" + query.Filter.Search + "%"
-			baseQuery = baseQuery.Where(
-				fmt.Sprintf("%s.full_name LIKE ? OR %s.email LIKE ?", tableName, tableName),
-				searchPattern, searchPattern)
+		if match.Where != nil {
+			baseQuery = baseQuery.Where(match.Where)
 		}
 
 		tableUser := domain.User{}.TableName()
@@ -56,26 +99,28 @@ func (repo userRepo) UserDashboardList(ctx context.Context, query UserListQuery)
 		return baseQuery
 	}
 
-	mobileSql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+	mobileSql := conn.ToSQL(func(tx *gorm.DB) *gorm.DB {
 		return buildConditions(
 			tx.
 				Model(&domain.User{}).
-				Select("`users`.id, full_name as name, email, if(is_verified=1, 'active','inactive') as status, last_active, 'USER' as role_name"),
+				Select("users.id, full_name as name, email, CASE WHEN is_verified = 1 THEN 'active' ELSE 'inactive' END as status, last_active, 'USER' as role_name, ? as relevance", userMatch.Relevance),
 			domain.User{}.TableName(),
 			"is_verified",
+			userMatch,
 		).
 			Where("is_verified = 1").
 			Find(&[]domain.User{})
 	})
 
-	dashboardSql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+	dashboardSql := conn.ToSQL(func(tx *gorm.DB) *gorm.DB {
 		return buildConditions(
 			tx.
 				Model(&domain.UserAdmin{}).
-				Select("`user_admins`.id, full_name as name, email, if(is_active=1, 'active','inactive') as status, last_active, master_roles.name as role_name").
+				Select("user_admins.id, full_name as name, email, CASE WHEN is_active = 1 THEN 'active' ELSE 'inactive' END as status, last_active, master_roles.name as role_name, ? as relevance", adminMatch.Relevance).
 				Joins("left join master_roles on master_roles.id = user_admins.role_id"),
 			domain.UserAdmin{}.TableName(),
-			"is_active").
+			"is_active",
+			adminMatch).
 			Find(&[]domain.UserAdmin{})
 	})
 
@@ -88,12 +133,17 @@ func (repo userRepo) UserDashboardList(ctx context.Context, query UserListQuery)
 		finalQuery = dashboardSql
 		break
 	default:
-		finalQuery = fmt.Sprintf("(%s) UNION ALL (%s)", mobileSql, dashboardSql)
+		// subquery instead of parenthesized select, sqlite does not support it in UNION
+		finalQuery = fmt.Sprintf("SELECT * FROM (%s) as mobile UNION ALL SELECT * FROM (%s) as dashboard", mobileSql, dashboardSql)
 	}
 
+	unionTable := conn.
+		Table(fmt.Sprintf("(%s) as union_table", finalQuery)).
+		Scopes(query.Query.Where).
+		Session(&gorm.Session{})
+
 	var total int64
-	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM (%s) as union_count", finalQuery)
-	err := db.Raw(countSQL).Scan(&total).Error
+	err := unionTable.Count(&total).Error
 	if err != nil {
 		return nil, 0, 0, fmt.Errorf("failed to count results: %w", err)
 	}
@@ -102,14 +152,12 @@ func (repo userRepo) UserDashboardList(ctx context.Context, query UserListQuery)
 	totalPages := int((total + int64(query.Filter.PerPage) - 1) / int64(query.Filter.PerPage))
 
 	// Execute union query with pagination and sorting
-	finalSQL := fmt.Sprintf(`
-		SELECT * FROM (%s) as union_table 
-		ORDER BY last_active DESC 
-		LIMIT ? OFFSET ?
-	`, finalQuery)
-
 	var results []domain.UserListItem
-	err = db.Raw(finalSQL, query.Filter.PerPage, offset).Scan(&results).Error
+	err = unionTable.
+		Scopes(order.Sort).
+		Limit(query.Filter.PerPage).
+		Offset(offset).
+		Scan(&results).Error
 	if err != nil {
 		return nil, 0, 0, fmt.Errorf("failed to execute union query: %w", err)
 	}
@@ -11,4 +11,7 @@ const (
 
 	RoleIsAdmin = "ADMIN"
 	RoleIsUser  = "USER"
+
+	// deleted account can be restored within retention window, purged after
+	DefaultAccountRetentionDays = 30
 )
@@ -7,11 +7,11 @@ import (
 )
 
 type BaseEntity struct {
-	ID        uint      `gorm:"primaryKey;type:BIGINT UNSIGNED AUTO_INCREMENT" json:"id"`
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime:false" json:"createdAt"`
-	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime:false" json:"updatedAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime:false" audit:"-" json:"updatedAt"`
 	CreatedBy string    `gorm:"column:created_by;type:varchar(100)" json:"createdBy"`
-	UpdatedBy string    `gorm:"column:updated_by;type:varchar(100)" json:"updatedBy"`
+	UpdatedBy string    `gorm:"column:updated_by;type:varchar(100)" audit:"-" json:"updatedBy"`
 }
 
 func (receiver *BaseEntity) SetCreated(actor string) {
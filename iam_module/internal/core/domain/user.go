@@ -1,23 +1,26 @@
 package domain
 
 import (
+	"base-be-golang/pkg/db"
 	"database/sql"
 	"time"
 )
 
 type User struct {
 	BaseEntity
+	db.TenantScope
+	db.SoftDelete
 	Code       string       `json:"code"`
 	Profile    string       `json:"profile"`
 	FullName   string       `json:"fullName"`
 	Phone      string       `json:"phone"`
 	Email      string       `json:"email"`
-	Password   string       `json:"password"`
+	Password   string       `audit:"mask" json:"password"`
 	IsVerified int32        `gorm:"column:is_verified" json:"isVerified"`
-	OTPCode    int32        `gorm:"column:otp_code" json:"otpCode"`
-	AuthCode   string       `json:"authCode"`
+	OTPCode    int32        `gorm:"column:otp_code" audit:"-" json:"otpCode"`
+	AuthCode   string       `audit:"-" json:"authCode"`
 	Lang       string       `json:"lang"`
-	LastActive sql.NullTime `gorm:"column:last_active" json:"lastActive"`
+	LastActive sql.NullTime `gorm:"column:last_active" audit:"-" json:"lastActive"`
 }
 
 func (receiver User) GetRoleName() string {
@@ -62,6 +65,15 @@ func (receiver *User) SetName(name string)         { receiver.FullName = name }
 func (receiver *User) SetEmail(email string)       { receiver.Email = email }
 func (receiver *User) SetPassword(password string) { receiver.Password = password }
 func (receiver *User) SetAuthCode(code string)     { receiver.AuthCode = code }
+func (receiver User) AuditEntity() string {
+	return receiver.TableName()
+}
+
 func (receiver User) TableName() string {
 	return "users"
 }
+
+// SearchColumns full_name and email, the FULLTEXT index idx_users_fulltext
+func (receiver User) SearchColumns() []string {
+	return []string{"full_name", "email"}
+}
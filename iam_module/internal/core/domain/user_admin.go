@@ -1,22 +1,26 @@
 package domain
 
 import (
+	"base-be-golang/pkg/db"
 	"database/sql"
 	"time"
 )
 
 type UserAdmin struct {
 	BaseEntity
+	db.TenantScope
+	db.SoftDelete
+	db.OptimisticLock
 	Code       string       `json:"code"`
 	FullName   string       `json:"fullName"`
 	Email      string       `json:"email"`
 	Phone      string       `json:"phone"`
 	Role       MasterRole   `gorm:"foreignKey:RoleID" json:"role"`
 	RoleID     uint         `gorm:"column:role_id" json:"roleID"`
-	Password   string       `json:"password"`
-	AuthCode   string       `json:"authCode"`
+	Password   string       `audit:"mask" json:"password"`
+	AuthCode   string       `audit:"-" json:"authCode"`
 	IsActive   int32        `gorm:"column:is_active" json:"isActive"`
-	LastActive sql.NullTime `gorm:"column:last_active" json:"lastActive"`
+	LastActive sql.NullTime `gorm:"column:last_active" audit:"-" json:"lastActive"`
 }
 
 const (
@@ -77,6 +81,15 @@ func (receiver *UserAdmin) SetName(name string)         { receiver.FullName = na
 func (receiver *UserAdmin) SetEmail(email string)       { receiver.Email = email }
 func (receiver *UserAdmin) SetPassword(password string) { receiver.Password = password }
 func (receiver *UserAdmin) SetAuthCode(code string)     { receiver.AuthCode = code }
+func (receiver UserAdmin) AuditEntity() string {
+	return receiver.TableName()
+}
+
 func (receiver UserAdmin) TableName() string {
 	return "user_admins"
 }
+
+// SearchColumns full_name and email, the FULLTEXT index idx_user_admins_fulltext
+func (receiver UserAdmin) SearchColumns() []string {
+	return []string{"full_name", "email"}
+}
@@ -20,6 +20,7 @@ type UserEntityInterface interface {
 	GetCreatedAt() time.Time
 	GetRoleName() string
 	GetUpdatedAt() time.Time
+	GetTenantID() string
 }
 
 type UserListItem struct {
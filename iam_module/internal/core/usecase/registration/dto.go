@@ -1,5 +1,7 @@
 package registration
 
+import "time"
+
 type RegisterRequest struct {
 	FullName string `json:"fullName" binding:"required"`
 	Email    string `json:"email" binding:"required"`
@@ -7,21 +9,54 @@ type RegisterRequest struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Timezone string `json:"timezone"`
-	Role     string `json:"-"`
+	Email     string `json:"email" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	Timezone  string `json:"timezone"`
+	Role      string `json:"-"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
 type RegisterResponse struct {
 	UserID uint `json:"userId"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"-"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+type SessionItem struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
 type LoginResponse struct {
-	Email      string `json:"email"`
-	UserID     uint   `json:"userId"`
-	Token      string `json:"token"`
-	IsVerified bool   `json:"isVerified"`
+	Email        string `json:"email"`
+	UserID       uint   `json:"userId"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+	IsVerified   bool   `json:"isVerified"`
 }
 
 type SendOtpRequest struct {
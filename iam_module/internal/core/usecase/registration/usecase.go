@@ -1,19 +1,24 @@
 package registration
 
 import (
+	"base-be-golang/pkg/davinci"
 	"base-be-golang/pkg/db"
 	"base-be-golang/pkg/localerror"
 	"base-be-golang/pkg/mailing"
 	"base-be-golang/pkg/middleware"
+	"base-be-golang/pkg/tenant"
 	"base-be-golang/shared/base"
 	"base-be-golang/shared/payload"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -36,37 +41,53 @@ type Usecase struct {
 
 type auth interface {
 	GenerateSingleToken(claim security.SingleTokenClaim) (string, error)
+	AccessTokenTTL() time.Duration
+	IssueRefreshToken(ctx context.Context, token security.RefreshToken) (string, security.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, raw string) (security.RefreshToken, error)
+	StartSession(ctx context.Context, session security.Session) (security.Session, error)
+	FindSession(ctx context.Context, id string) (security.Session, error)
+	UserSessions(ctx context.Context, userContext string, userID uint) ([]security.Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeUserSessions(ctx context.Context, userContext string, userID uint, keep ...string) error
+	ResetTokenTTL() time.Duration
+	IssueResetToken(ctx context.Context, token security.ResetToken) (string, error)
+	ConsumeResetToken(ctx context.Context, raw string) (security.ResetToken, error)
 }
 
 func NewUsecase(dbConn *gorm.DB, port base.Port) Usecase {
 	return Usecase{
-		auth:          security.NewAuth(),
+		auth:          security.NewAuth(port.Cache),
 		Port:          port,
 		userAdminRepo: db.NewGenericeRepo[domain.UserAdmin](dbConn, domain.UserAdmin{}),
 		userRepo:      db.NewGenericeRepo[domain.User](dbConn, domain.User{}),
 	}
 }
 
-func (u Usecase) Logout(ctx context.Context, role string) error {
+// Logout end the session of the device, the other devices of the user stay logged in
+func (u Usecase) Logout(ctx context.Context) error {
 	userSession := u.Security.GetUserContext(ctx)
-	switch role {
-	case constant.ContextDashboard:
-		var user domain.UserAdmin
-		err := setLogout(ctx, u.userRepo, &user)
-		if err != nil {
-			return u.ErrHandler.ErrorReturn(err)
-		}
-		break
-	case constant.ContextMobile:
-		var user domain.User
-		err := setLogout(ctx, u.userRepo, &user)
-		if err != nil {
-			return u.ErrHandler.ErrorReturn(err)
-		}
-		break
+	err := u.auth.RevokeSession(ctx, userSession.SessionID)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+// LogoutAll end every session of the user, log out everywhere
+func (u Usecase) LogoutAll(ctx context.Context) error {
+	userSession := u.Security.GetUserContext(ctx)
+	current, err := u.auth.FindSession(ctx, userSession.SessionID)
+	if err != nil {
+		return err
+	}
+
+	err = u.auth.RevokeUserSessions(ctx, current.Context, current.UserID)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
 	}
 
-	err := u.Cache.Delete(ctx, fmt.Sprintf("%s%s", constant.CacheKeyLogin, userSession.UserId))
+	err = u.Cache.Delete(ctx, fmt.Sprintf("%s%s", constant.CacheKeyLogin, userSession.UserId))
 	if err != nil {
 		u.ErrHandler.ErrorPrint(err)
 		middleware.CaptureErrorUsecase(ctx, err)
@@ -75,30 +96,56 @@ func (u Usecase) Logout(ctx context.Context, role string) error {
 	return nil
 }
 
-type userLogout struct {
-	ID       string `gorm:"column:id" json:"id"`
-	AuthCode string `gorm:"column:auth_code" json:"auth_code"`
+// ListSessions active sessions of the user, one per logged in device
+func (u Usecase) ListSessions(ctx context.Context) ([]SessionItem, error) {
+	userSession := u.Security.GetUserContext(ctx)
+	current, err := u.auth.FindSession(ctx, userSession.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.auth.UserSessions(ctx, current.Context, current.UserID)
+	if err != nil {
+		return nil, u.ErrHandler.ErrorReturn(err)
+	}
+
+	var result = make([]SessionItem, len(sessions))
+	for i, session := range sessions {
+		result[i] = SessionItem{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == current.ID,
+		}
+	}
+	return result, nil
 }
 
-func setLogout[T schema.Tabler](ctx context.Context, repo db.GenericRepository[T], user domain.UserEntityInterface) error {
-	var ul userLogout
-	err := repo.FindOneByExpSelection(ctx,
-		&ul,
-		[]clause.Expression{db.Equal(user.GetAuthCode(), "auth_code")},
-	)
-	err = localerror.AccessNotAllowedUserNotFound(err)
+// RevokeSession end one session of the user, session of another user is answered as not found
+func (u Usecase) RevokeSession(ctx context.Context, id string) error {
+	userSession := u.Security.GetUserContext(ctx)
+	current, err := u.auth.FindSession(ctx, userSession.SessionID)
 	if err != nil {
 		return err
 	}
 
-	user.SetAuthCode("EXPIRED")
-	user.SetID(user.GetID())
-	d := user.(T)
-	err = repo.UpdateSelectedCols(ctx, d, "auth_code")
+	target, err := u.auth.FindSession(ctx, id)
+	if localerror.IsAccessNotAllowedUserNotFound(err) ||
+		(err == nil && (target.Context != current.Context || target.UserID != current.UserID)) {
+		return localerror.InvalidData(constant2.SessionNotFound.String())
+	}
 	if err != nil {
 		return err
 	}
 
+	err = u.auth.RevokeSession(ctx, target.ID)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
 	return nil
 }
 
@@ -112,10 +159,15 @@ func (u Usecase) Login(ctx context.Context, request LoginRequest) (LoginResponse
 	)
 	switch request.Role {
 	case constant.ContextMobile:
-		data, err := u.userRepo.FindOneByExpression(ctx, []clause.Expression{
+		cond := []clause.Expression{
 			db.Equal(request.Email, "email"),
 			db.Equal(true, "is_verified"),
-		})
+		}
+		err = checkEmailTenant(ctx, u.userRepo, cond)
+		if err != nil {
+			return LoginResponse{}, err
+		}
+		data, err := u.userRepo.FindOneByExpression(ctx, cond)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return LoginResponse{}, localerror.InvalidData(constant2.LoginPasswordMismatch.String())
@@ -130,10 +182,12 @@ func (u Usecase) Login(ctx context.Context, request LoginRequest) (LoginResponse
 		}
 		break
 	case constant.ContextDashboard:
-		data, err := u.userAdminRepo.FindOneByExpressionAndJoin(
-			ctx,
-			[]clause.Expression{db.Equal(request.Email, "email")},
-			[]string{"Role"}, nil)
+		cond := []clause.Expression{db.Equal(request.Email, "email")}
+		err = checkEmailTenant(ctx, u.userAdminRepo, cond)
+		if err != nil {
+			return LoginResponse{}, err
+		}
+		data, err := u.userAdminRepo.FindOneByExpressionAndJoin(ctx, cond, []string{"Role"}, nil)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return LoginResponse{}, localerror.InvalidData(constant2.LoginPasswordMismatch.String())
@@ -145,11 +199,17 @@ func (u Usecase) Login(ctx context.Context, request LoginRequest) (LoginResponse
 		break
 	}
 
-	if rawPas, err := u.Davinci.DecryptMessage([]byte(u.Env.Get("ENCRYPT_MESSAGE_PASSWORD")), user.GetPassword()); err != nil {
+	match, rehash, err := u.checkPassword(user.GetPassword(), request.Password)
+	if err != nil {
 		return LoginResponse{}, err
-	} else if rawPas != request.Password {
+	}
+	if !match {
 		return LoginResponse{}, localerror.InvalidData(constant2.LoginPasswordMismatch.String())
 	}
+	var passwordHash string
+	if rehash {
+		passwordHash = u.rehashPassword(ctx, request.Password)
+	}
 
 	userReference, err := u.Davinci.GenerateHash([]byte(u.Env.Get("SECRET_USER_ID")), strconv.FormatUint(uint64(user.GetID()), 10))
 	if err != nil {
@@ -160,7 +220,10 @@ func (u Usecase) Login(ctx context.Context, request LoginRequest) (LoginResponse
 		UserId:   userReference,
 		Email:    user.GetEmail(),
 		Timezone: u.Env.Get("FALLBACK_TIMEZONE"),
+		TenantID: user.GetTenantID(),
 	}
+	// session cache and write below belong to the tenant of the user, also when login came without tenant header
+	ctx = tenant.With(ctx, user.GetTenantID())
 
 	if request.Timezone != "" {
 		userDataToken.Timezone = request.Timezone
@@ -176,22 +239,167 @@ func (u Usecase) Login(ctx context.Context, request LoginRequest) (LoginResponse
 		userDataToken.Lang = lang
 		userDataToken.RoleName = constant.RolesIsMobile
 		userMobile.AuthCode = userReference
-		err = u.userRepo.UpdateSelectedCols(ctx, userMobile, "auth_code", "lang")
+		cols := []string{"auth_code", "lang"}
+		if passwordHash != "" {
+			userMobile.Password = passwordHash
+			cols = append(cols, "password")
+		}
+		err = u.userRepo.UpdateSelectedCols(db.WithoutVersion(ctx), userMobile, cols...)
 		if err != nil {
 			return LoginResponse{}, err
 		}
 	} else {
 		userAdmin.AuthCode = userReference
 		userDataToken.RoleName = userAdmin.Role.Name
-		err = u.userAdminRepo.UpdateSelectedCols(ctx, userAdmin, "auth_code")
+		cols := []string{"auth_code"}
+		if passwordHash != "" {
+			userAdmin.Password = passwordHash
+			cols = append(cols, "password")
+		}
+		err = u.userAdminRepo.UpdateSelectedCols(db.WithoutVersion(ctx), userAdmin, cols...)
 		if err != nil {
 			return LoginResponse{}, err
 		}
 	}
 
+	session, err := u.auth.StartSession(ctx, security.Session{
+		UserID:    user.GetID(),
+		Context:   request.Role,
+		TenantID:  user.GetTenantID(),
+		UserAgent: request.UserAgent,
+		IP:        request.IP,
+	})
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	return u.issueTokens(ctx, user, userDataToken, security.RefreshToken{
+		SessionID: session.ID,
+		UserID:    user.GetID(),
+		Context:   request.Role,
+		TenantID:  user.GetTenantID(),
+		Timezone:  userDataToken.Timezone,
+	})
+}
+
+/*
+checkPassword compare password with the stored one, rehash is true when it must be stored again:
+password encrypted by the older version (EncryptMessage) or hashed with another cost.
+*/
+func (u Usecase) checkPassword(stored string, password string) (match bool, rehash bool, err error) {
+	if u.Davinci.IsPasswordHash(stored) {
+		match, err = u.Davinci.ComparePassword(stored, []byte(password))
+		if err != nil || !match {
+			return false, false, err
+		}
+		return true, u.Davinci.NeedsRehash(stored), nil
+	}
+
+	rawPas, err := u.Davinci.DecryptMessage([]byte(u.Env.Get("ENCRYPT_MESSAGE_PASSWORD")), stored)
+	if err != nil {
+		return false, false, err
+	}
+	match = subtle.ConstantTimeCompare([]byte(rawPas), []byte(password)) == 1
+	return match, match, nil
+}
+
+// rehashPassword hash of the password, empty when hashing fail so login still go on with the stored one
+func (u Usecase) rehashPassword(ctx context.Context, password string) string {
+	hash, err := u.Davinci.HashAndSalt([]byte(password))
+	if err != nil {
+		u.ErrHandler.ErrorPrint(err)
+		middleware.CaptureErrorUsecase(ctx, err)
+		return ""
+	}
+	return hash
+}
+
+/*
+Refresh rotate the refresh token and return a new access token, the user is read again
+so a changed role or a deactivated user is seen at the next refresh.
+*/
+func (u Usecase) Refresh(ctx context.Context, request RefreshRequest) (LoginResponse, error) {
+	refresh, err := u.auth.RotateRefreshToken(ctx, request.RefreshToken)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	if !security.SameTenant(ctx, refresh.TenantID) {
+		return LoginResponse{}, u.endSession(ctx, refresh, localerror.AccessControlError{Msg: constant2.AccessNotAllowed.String()})
+	}
+	ctx = tenant.With(ctx, refresh.TenantID)
+
+	userDataToken := security.UserData{
+		Timezone: refresh.Timezone,
+		TenantID: refresh.TenantID,
+	}
+	var user domain.UserEntityInterface
+	switch refresh.Context {
+	case constant.ContextMobile:
+		data, err := u.userRepo.FindOneByExpression(ctx, []clause.Expression{
+			db.Equal(refresh.UserID, "id"),
+			db.Equal(true, "is_verified"),
+		})
+		if err != nil {
+			return LoginResponse{}, u.endSession(ctx, refresh, err)
+		}
+		user = &data
+		userDataToken.Lang = data.Lang
+		if userDataToken.Lang == "" {
+			userDataToken.Lang = u.Env.Get("FALLBACK_LANG")
+		}
+		userDataToken.RoleName = constant.RolesIsMobile
+	case constant.ContextDashboard:
+		data, err := u.userAdminRepo.FindOneByExpressionAndJoin(
+			ctx,
+			[]clause.Expression{
+				db.Equal(refresh.UserID, "user_admins.id"),
+				db.Equal(1, "user_admins.is_active"),
+			},
+			[]string{"Role"}, nil)
+		if err != nil {
+			return LoginResponse{}, u.endSession(ctx, refresh, err)
+		}
+		user = &data
+		userDataToken.RoleName = data.Role.Name
+	default:
+		return LoginResponse{}, u.endSession(ctx, refresh, gorm.ErrRecordNotFound)
+	}
+
+	// auth code expired by logout before session existed, refresh token of that login must not bring it back
+	if user.GetAuthCode() == "" || user.GetAuthCode() == "EXPIRED" {
+		return LoginResponse{}, u.endSession(ctx, refresh, gorm.ErrRecordNotFound)
+	}
+	userDataToken.UserId = user.GetAuthCode()
+	userDataToken.Email = user.GetEmail()
+
+	return u.issueTokens(ctx, user, userDataToken, refresh)
+}
+
+// endSession revoke the session of refresh which failed, user not found is answered as expired session
+func (u Usecase) endSession(ctx context.Context, refresh security.RefreshToken, err error) error {
+	if errRevoke := u.auth.RevokeSession(ctx, refresh.SessionID); errRevoke != nil {
+		u.ErrHandler.ErrorPrint(errRevoke)
+	}
+	return localerror.AccessNotAllowedUserNotFound(err)
+}
+
+// issueTokens sign the access token with the next refresh token of the session, and cache the login of the user
+func (u Usecase) issueTokens(
+	ctx context.Context,
+	user domain.UserEntityInterface,
+	userDataToken security.UserData,
+	refresh security.RefreshToken,
+) (LoginResponse, error) {
+	refreshToken, refresh, err := u.auth.IssueRefreshToken(ctx, refresh)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
 	token, err := u.auth.GenerateSingleToken(security.SingleTokenClaim{
 		UserData: userDataToken,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       refresh.SessionID,
 			Issuer:   strconv.FormatUint(uint64(user.GetID()), 10),
 			IssuedAt: jwt.NewNumericDate(u.Clock.Now(ctx)),
 		},
@@ -203,19 +411,21 @@ func (u Usecase) Login(ctx context.Context, request LoginRequest) (LoginResponse
 	userBytes, err := json.Marshal(user)
 	err = u.Cache.Set(
 		ctx,
-		fmt.Sprintf("%s%s", constant.CacheKeyLogin, userReference),
+		fmt.Sprintf("%s%s", constant.CacheKeyLogin, userDataToken.UserId),
 		string(userBytes),
-		time.Hour*time.Duration(u.Env.GetInt("EXPIRED_TOKEN_JWT", 1)),
+		time.Until(refresh.ExpiresAt),
 	)
 	if err != nil {
 		middleware.CaptureErrorUsecase(ctx, err)
 	}
 
 	return LoginResponse{
-		UserID:     user.GetID(),
-		Email:      user.GetEmail(),
-		Token:      token,
-		IsVerified: user.GetIsVerified(),
+		UserID:       user.GetID(),
+		Email:        user.GetEmail(),
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(u.auth.AccessTokenTTL().Seconds()),
+		IsVerified:   user.GetIsVerified(),
 	}, nil
 }
 
@@ -234,45 +444,56 @@ func (u Usecase) Register(ctx context.Context, request RegisterRequest) (Registe
 		return RegisterResponse{}, localerror.InvalidData(constant2.RegisterEmailUsed.String())
 	}
 
-	encryptMessage, err := u.Davinci.EncryptMessage([]byte(u.Env.Get("ENCRYPT_MESSAGE_PASSWORD")), []byte(request.Password))
-	if err != nil {
-		return RegisterResponse{}, err
+	passwordHash, err := u.Davinci.HashAndSalt([]byte(request.Password))
+	if errors.Is(err, davinci.ErrPasswordTooLong) {
+		return RegisterResponse{}, localerror.InvalidData(constant2.PasswordTooLong.String())
 	}
-
-	user := domain.User{
-		Email:      request.Email,
-		Password:   encryptMessage,
-		FullName:   request.FullName,
-		IsVerified: 0,
-	}
-	user.SetCreated("system")
-	user, err = u.userRepo.Store(ctx, user)
 	if err != nil {
 		return RegisterResponse{}, err
 	}
 
-	if u.Env.CheckFlag("EMAIL_VERIFICATION_OFF") {
-		user.SetIsVerified(true)
-		err = u.userRepo.UpdateSelectedCols(ctx, user, "is_verified")
+	var (
+		user         domain.User
+		verification = !u.Env.CheckFlag("EMAIL_VERIFICATION_OFF")
+	)
+	// only db writes inside, closure is retried on deadlock. user is not kept when otp setup fail
+	err = u.UnitOfWork.WithinTransaction(ctx, func(ctx context.Context) error {
+		stored, err := u.userRepo.Store(ctx, domain.User{
+			Email:      request.Email,
+			Password:   passwordHash,
+			FullName:   request.FullName,
+			IsVerified: 0,
+		})
 		if err != nil {
-			return RegisterResponse{}, err
+			return err
 		}
-	} else {
-		otpResult, err := u.GenerateAndSendOTP(
-			ctx,
-			SendOtpRequest{
-				Name:   request.FullName,
-				UserID: uint64(user.ID),
-				Email:  request.Email,
-			},
-			false,
-		)
+
+		if !verification {
+			stored.SetIsVerified(true)
+			err = u.userRepo.UpdateSelectedCols(db.WithoutVersion(ctx), stored, "is_verified")
+			user = stored
+			return err
+		}
+
+		otp, err := u.newOTP()
 		if err != nil {
-			return RegisterResponse{}, err
+			return err
 		}
+		stored.OTPCode = int32(otp)
+		err = u.userRepo.UpdateSelectedCols(db.WithoutVersion(ctx), stored, "otp_code")
+		user = stored
+		return err
+	})
+	if err != nil {
+		return RegisterResponse{}, err
+	}
 
-		user.OTPCode = otpResult.Otp
-		err = u.userRepo.UpdateSelectedCols(ctx, user, "otp_code")
+	if verification {
+		err = u.sendOTP(ctx, SendOtpRequest{
+			Name:   request.FullName,
+			UserID: uint64(user.ID),
+			Email:  request.Email,
+		}, int(user.OTPCode))
 		if err != nil {
 			return RegisterResponse{}, err
 		}
@@ -282,6 +503,8 @@ func (u Usecase) Register(ctx context.Context, request RegisterRequest) (Registe
 }
 
 func (u Usecase) VerifyAcc(ctx context.Context, request VerifyAccRequest) (VerifyAccResponse, error) {
+	// otp code is written just before by register / resend otp, replica may not have it yet
+	ctx = db.ReadYourWrites(ctx)
 	user, err := u.userRepo.FindOneByExpression(ctx, []clause.Expression{db.Equal(request.Email, "email")})
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -308,7 +531,7 @@ func (u Usecase) VerifyAcc(ctx context.Context, request VerifyAccRequest) (Verif
 	}
 
 	user.SetIsVerified(true)
-	err = u.userRepo.UpdateSelectedCols(ctx, user, "is_verified")
+	err = u.userRepo.UpdateSelectedCols(db.WithoutVersion(ctx), user, "is_verified")
 	if err != nil {
 		return VerifyAccResponse{}, err
 	}
@@ -371,24 +594,13 @@ func (u Usecase) GenerateAndSendOTP(
 		}
 	}
 
-	movingFactor := uint64(u.Clock.NowUnix() / 30)
-	secret := u.Env.Get("HOTP_SECRET")
-	otp, err := u.Davinci.GenerateOTPCode(secret, movingFactor)
+	otp, err := u.newOTP()
 	if err != nil {
 
 		return SendOtpResponse{}, err
 	}
 
-	otpStr := strconv.Itoa(otp)
-
-	var tmplData = payload.EmailBodyVerifyOTPPayload{
-		Name:       emailPayload.Name,
-		OTPs:       strings.Split(otpStr, ""),
-		VerifyPage: os.Getenv("FRONT_END_HOST") + "/register/verifikasi/" + strconv.Itoa(int(emailPayload.UserID)),
-	}
-	emailPayload.Content, err = u.GenerateEmailBodyVerifyOTP(ctx, tmplData)
-	emailPayload.Subject = "Register User Verification"
-	err = u.sendEmail(emailPayload)
+	err = u.sendOTP(ctx, emailPayload, otp)
 	if err != nil {
 
 		return SendOtpResponse{}, err
@@ -397,23 +609,47 @@ func (u Usecase) GenerateAndSendOTP(
 	if regenerate {
 		user.OTPCode = int32(otp)
 		user.IsVerified = 0
-		err := u.userRepo.UpdateSelectedCols(ctx, user, "otp_code", "is_verified")
+		err := u.userRepo.UpdateSelectedCols(db.WithoutVersion(ctx), user, "otp_code", "is_verified")
 		if err != nil {
 
 			return SendOtpResponse{}, err
 		}
 	}
 
-	err = u.Cache.Set(ctx, constant.CacheKeyOTP+otpStr, true, time.Minute*time.Duration(u.Env.GetUint("EXPARATION_OTP_TIME", 0)))
-	if err != nil {
-		return SendOtpResponse{}, err
-	}
-
 	return SendOtpResponse{
 		Otp: int32(otp),
 	}, nil
 }
 
+func (u Usecase) newOTP() (int, error) {
+	movingFactor := uint64(u.Clock.NowUnix() / 30)
+	secret := u.Env.Get("HOTP_SECRET")
+	return u.Davinci.GenerateOTPCode(secret, movingFactor)
+}
+
+// sendOTP email the otp and mark it valid in cache, call it outside transaction since it can not be rolled back
+func (u Usecase) sendOTP(ctx context.Context, emailPayload SendOtpRequest, otp int) error {
+	otpStr := strconv.Itoa(otp)
+
+	var tmplData = payload.EmailBodyVerifyOTPPayload{
+		Name:       emailPayload.Name,
+		OTPs:       strings.Split(otpStr, ""),
+		VerifyPage: os.Getenv("FRONT_END_HOST") + "/register/verifikasi/" + strconv.Itoa(int(emailPayload.UserID)),
+	}
+	var err error
+	emailPayload.Content, err = u.GenerateEmailBodyVerifyOTP(ctx, tmplData)
+	if err != nil {
+		return err
+	}
+	emailPayload.Subject = "Register User Verification"
+	err = u.sendEmail(emailPayload)
+	if err != nil {
+		return err
+	}
+
+	return u.Cache.Set(ctx, constant.CacheKeyOTP+otpStr, true, time.Minute*time.Duration(u.Env.GetUint("EXPARATION_OTP_TIME", 0)))
+}
+
 func (u Usecase) sendEmail(emailPayload SendOtpRequest) error {
 	err := u.Mailing.NativeSendEmail(mailing.NativeSendEmailPayload{
 		Host:     os.Getenv("SMPT_SERVER_HOST"),
@@ -429,3 +665,268 @@ func (u Usecase) sendEmail(emailPayload SendOtpRequest) error {
 	}
 	return nil
 }
+
+// ===================== PASSWORD ======================
+
+/*
+ForgotPassword email a single-use reset link to the user, unknown email answer the same
+and the email is sent in background, so the endpoint can not tell which email is registered.
+*/
+func (u Usecase) ForgotPassword(ctx context.Context, request ForgotPasswordRequest) error {
+	var user domain.UserEntityInterface
+	switch request.Role {
+	case constant.ContextMobile:
+		cond := []clause.Expression{
+			db.Equal(request.Email, "email"),
+			db.Equal(true, "is_verified"),
+		}
+		err := checkEmailTenant(ctx, u.userRepo, cond)
+		if err != nil {
+			return err
+		}
+		data, err := u.userRepo.FindOneByExpression(ctx, cond)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		user = &data
+	case constant.ContextDashboard:
+		cond := []clause.Expression{db.Equal(request.Email, "email")}
+		err := checkEmailTenant(ctx, u.userAdminRepo, cond)
+		if err != nil {
+			return err
+		}
+		data, err := u.userAdminRepo.FindOneByExpression(ctx, cond)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		user = &data
+	default:
+		return localerror.InvalidData(constant2.AccessNotAllowed.String())
+	}
+
+	// sent in background and only logged, so known email answer as fast as unknown one
+	bgCtx := context.WithoutCancel(ctx)
+	u.background(func() { u.sendResetPassword(bgCtx, user, request.Role) })
+	return nil
+}
+
+const (
+	mailWorkers   = 4
+	mailQueueSize = 256
+)
+
+var (
+	mailQueue      = make(chan func(), mailQueueSize)
+	mailWorkerOnce sync.Once
+)
+
+// background queue fn on the bounded mail workers, when the queue is full fn is dropped and logged
+// so a burst of request can't pile up goroutine nor slow the response
+func (u Usecase) background(fn func()) {
+	mailWorkerOnce.Do(func() {
+		for i := 0; i < mailWorkers; i++ {
+			go func() {
+				for job := range mailQueue {
+					job()
+				}
+			}()
+		}
+	})
+
+	job := func() {
+		defer func() {
+			if r := recover(); r != nil {
+				u.ErrHandler.ErrorPrint(fmt.Errorf("background mail panic: %v", r))
+			}
+		}()
+		fn()
+	}
+	select {
+	case mailQueue <- job:
+	default:
+		u.ErrHandler.ErrorPrint(errors.New("mail queue is full, email dropped"))
+	}
+}
+
+// sendResetPassword issue the reset token of user and email the link, error is printed since nobody waits for it
+func (u Usecase) sendResetPassword(ctx context.Context, user domain.UserEntityInterface, userContext string) {
+	raw, err := u.auth.IssueResetToken(ctx, security.ResetToken{
+		UserID:   user.GetID(),
+		Context:  userContext,
+		TenantID: user.GetTenantID(),
+	})
+	if err != nil {
+		u.ErrHandler.ErrorPrint(err)
+		return
+	}
+	if raw == "" {
+		return
+	}
+
+	ttl := u.auth.ResetTokenTTL()
+	content, err := u.GenerateEmailBodyResetPassword(ctx, payload.EmailBodyResetPasswordPayload{
+		Name:             user.GetName(),
+		ResetPage:        os.Getenv("FRONT_END_HOST") + "/reset-password?token=" + url.QueryEscape(raw),
+		ExpiresInMinutes: int(ttl.Minutes()),
+	})
+	if err != nil {
+		u.ErrHandler.ErrorPrint(err)
+		return
+	}
+
+	err = u.sendEmail(SendOtpRequest{
+		Email:   user.GetEmail(),
+		Subject: "Reset Password",
+		Content: content,
+	})
+	if err != nil {
+		u.ErrHandler.ErrorPrint(err)
+	}
+}
+
+// ResetPassword set the password of the reset token owner and end every session of the user
+func (u Usecase) ResetPassword(ctx context.Context, request ResetPasswordRequest) error {
+	// rejected password must not use up the token
+	hash, err := u.hashPassword(request.Password)
+	if err != nil {
+		return err
+	}
+
+	token, err := u.auth.ConsumeResetToken(ctx, request.Token)
+	if err != nil {
+		return err
+	}
+
+	if !security.SameTenant(ctx, token.TenantID) {
+		return localerror.InvalidData(constant2.ResetTokenInvalid.String())
+	}
+	ctx = tenant.With(ctx, token.TenantID)
+
+	user, err := u.findUser(ctx, token.Context, token.UserID)
+	if err != nil {
+		return localerror.NotFound(err, constant2.ResetTokenInvalid.String())
+	}
+
+	err = u.setPassword(ctx, token.Context, user, hash)
+	if err != nil {
+		return err
+	}
+
+	err = u.auth.RevokeUserSessions(ctx, token.Context, token.UserID)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	err = u.Cache.Delete(ctx, fmt.Sprintf("%s%s", constant.CacheKeyLogin, user.GetAuthCode()))
+	if err != nil {
+		u.ErrHandler.ErrorPrint(err)
+		middleware.CaptureErrorUsecase(ctx, err)
+	}
+
+	return nil
+}
+
+// ChangePassword set a new password after checking the current one, other sessions of the user are ended
+func (u Usecase) ChangePassword(ctx context.Context, request ChangePasswordRequest) error {
+	userSession := u.Security.GetUserContext(ctx)
+	current, err := u.auth.FindSession(ctx, userSession.SessionID)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.findUser(ctx, current.Context, current.UserID)
+	if err != nil {
+		return localerror.AccessNotAllowedUserNotFound(err)
+	}
+
+	match, _, err := u.checkPassword(user.GetPassword(), request.CurrentPassword)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return localerror.InvalidData(constant2.CurrentPasswordMismatch.String())
+	}
+
+	hash, err := u.hashPassword(request.NewPassword)
+	if err != nil {
+		return err
+	}
+	err = u.setPassword(ctx, current.Context, user, hash)
+	if err != nil {
+		return err
+	}
+
+	err = u.auth.RevokeUserSessions(ctx, current.Context, current.UserID, current.ID)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+/*
+checkEmailTenant InvalidData when the request came without tenant header and the email of cond is registered
+in more than one tenant, the first row found could belong to any of them.
+*/
+func checkEmailTenant[T schema.Tabler](ctx context.Context, repo db.GenericRepository[T], cond []clause.Expression) error {
+	if _, ok := tenant.FromContext(ctx); ok {
+		return nil
+	}
+
+	var result struct{ Tenants int }
+	err := repo.AggregateByExpression(ctx, &result, cond, db.AggregateQuery{
+		Select: []db.Aggregate{db.CountDistinct("tenant_id", "tenants")},
+	})
+	if err != nil {
+		return err
+	}
+	if result.Tenants > 1 {
+		return localerror.InvalidData(constant2.TenantRequired.String())
+	}
+	return nil
+}
+
+func (u Usecase) findUser(ctx context.Context, userContext string, id uint) (domain.UserEntityInterface, error) {
+	switch userContext {
+	case constant.ContextMobile:
+		user, err := u.userRepo.FindOneByID(ctx, id)
+		return &user, err
+	case constant.ContextDashboard:
+		user, err := u.userAdminRepo.FindOneByID(ctx, id)
+		return &user, err
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// hashPassword InvalidDataError when password is longer than bcrypt accept
+func (u Usecase) hashPassword(password string) (string, error) {
+	hash, err := u.Davinci.HashAndSalt([]byte(password))
+	if errors.Is(err, davinci.ErrPasswordTooLong) {
+		return "", localerror.InvalidData(constant2.PasswordTooLong.String())
+	}
+	return hash, err
+}
+
+// setPassword store the password hash on user
+func (u Usecase) setPassword(ctx context.Context, userContext string, user domain.UserEntityInterface, hash string) error {
+	user.SetPassword(hash)
+
+	var err error
+	switch userContext {
+	case constant.ContextMobile:
+		err = u.userRepo.UpdateSelectedCols(db.WithoutVersion(ctx), *user.(*domain.User), "password")
+	case constant.ContextDashboard:
+		err = u.userAdminRepo.UpdateSelectedCols(db.WithoutVersion(ctx), *user.(*domain.UserAdmin), "password")
+	}
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package user_management
 
 import (
+	"base-be-golang/pkg/db"
 	"base-be-golang/shared/payload"
 	"time"
 
@@ -16,6 +17,7 @@ type UserDetailItem struct {
 	LastActive  *time.Time `json:"lastActive"`
 	DestVisited int        `json:"destVisited"`
 	JoinAt      time.Time  `json:"joinAt"`
+	Version     uint       `json:"version"`
 }
 
 type UserVisitedDestDetail struct {
@@ -37,6 +39,11 @@ func DefaultUserDetailItem(item domain.UserEntityInterface) UserDetailItem {
 		status = domain.Inactive
 	}
 
+	var version uint
+	if versioned, ok := item.(db.Versioned); ok {
+		version = versioned.GetVersion()
+	}
+
 	return UserDetailItem{
 		ID:         item.GetID(),
 		Email:      item.GetEmail(),
@@ -44,6 +51,7 @@ func DefaultUserDetailItem(item domain.UserEntityInterface) UserDetailItem {
 		JoinAt:     item.GetCreatedAt(),
 		IsActive:   status,
 		LastActive: item.GetLastActive(),
+		Version:    version,
 	}
 }
 
@@ -54,6 +62,12 @@ type CreateUserRequest struct {
 	Password  string `json:"password"`
 	RoleId    uint   `json:"roleId"`
 	StatusKey string `json:"statusKey"`
+	Version   uint   `json:"version"`
+}
+
+type PurgeDeletedUserResponse struct {
+	Total       int       `json:"total"`
+	DeletedFrom time.Time `json:"deletedFrom"`
 }
 
 // ===================== USER MOBILE ======================
@@ -1,16 +1,21 @@
 package user_management
 
 import (
+	"base-be-golang/pkg/davinci"
 	"base-be-golang/pkg/db"
 	"base-be-golang/pkg/localerror"
 	"base-be-golang/shared/base"
 	"base-be-golang/shared/payload"
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/adapter/repository"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/constant"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/domain"
+	"github.com/rdhmuhammad/base-be-golang/iam-module/pkg/security"
 	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,11 +26,17 @@ type Usecase struct {
 	userAdminRepo db.GenericRepository[domain.UserAdmin]
 	userRepo      db.GenericRepository[domain.User]
 	userMainRepo  repository.UserRepo
+	sessions      sessions
+}
+
+type sessions interface {
+	RevokeUserSessions(ctx context.Context, userContext string, userID uint, keep ...string) error
 }
 
 func NewUsecase(gormDb *gorm.DB, port base.Port) Usecase {
 	return Usecase{
 		Port:          port,
+		sessions:      security.NewAuth(port.Cache),
 		userMainRepo:  repository.NewUserRepo(gormDb),
 		userAdminRepo: db.NewGenericeRepo(gormDb, domain.UserAdmin{}),
 		userRepo:      db.NewGenericeRepo(gormDb, domain.User{}),
@@ -38,11 +49,137 @@ const (
 )
 
 func (u Usecase) DeleteUser(ctx context.Context, id uint) error {
-	return u.userAdminRepo.DeleteByID(ctx, id)
+	err := u.userAdminRepo.DeleteByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return u.sessions.RevokeUserSessions(ctx, constant.ContextDashboard, id)
+}
+
+// ForceLogout end every session of the admin user, token already issued stop working at the next request
+func (u Usecase) ForceLogout(ctx context.Context, id uint) error {
+	user, err := u.userAdminRepo.FindOneByID(ctx, id)
+	if err != nil {
+		err = localerror.NotFound(err, constant2.UserNotFound.String())
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return u.forceLogout(ctx, constant.ContextDashboard, &user)
+}
+
+// ForceLogoutAccount end every session of the mobile user
+func (u Usecase) ForceLogoutAccount(ctx context.Context, id uint) error {
+	user, err := u.userRepo.FindOneByID(ctx, id)
+	if err != nil {
+		err = localerror.NotFound(err, constant2.UserNotFound.String())
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return u.forceLogout(ctx, constant.ContextMobile, &user)
+}
+
+func (u Usecase) forceLogout(ctx context.Context, userContext string, user domain.UserEntityInterface) error {
+	err := u.sessions.RevokeUserSessions(ctx, userContext, user.GetID())
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	err = u.Cache.Delete(ctx, fmt.Sprintf("%s%s", constant.CacheKeyLogin, user.GetAuthCode()))
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+func (u Usecase) RestoreUser(ctx context.Context, id uint) error {
+	user, err := u.userAdminRepo.FindOneByIDWithTrashed(ctx, id)
+	if err != nil {
+		err = localerror.NotFound(err, constant2.UserNotFound.String())
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	err = u.validateRestore(user.GetDeletedAt())
+	if err != nil {
+		return err
+	}
+
+	err = u.userAdminRepo.Restore(ctx, id)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+func (u Usecase) RestoreAccount(ctx context.Context, id uint) error {
+	user, err := u.userRepo.FindOneByIDWithTrashed(ctx, id)
+	if err != nil {
+		err = localerror.NotFound(err, constant2.UserNotFound.String())
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	err = u.validateRestore(user.GetDeletedAt())
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepo.Restore(ctx, id)
+	if err != nil {
+		return u.ErrHandler.ErrorReturn(err)
+	}
+
+	return nil
+}
+
+// PurgeDeletedUser permanently remove admin and mobile users deleted longer than retention window
+func (u Usecase) PurgeDeletedUser(ctx context.Context) (PurgeDeletedUserResponse, error) {
+	before := u.retentionStart()
+
+	totalAdmin, err := u.userAdminRepo.PurgeOlderThan(ctx, before)
+	if err != nil {
+		return PurgeDeletedUserResponse{}, u.ErrHandler.ErrorReturn(err)
+	}
+
+	totalMobile, err := u.userRepo.PurgeOlderThan(ctx, before)
+	if err != nil {
+		return PurgeDeletedUserResponse{}, u.ErrHandler.ErrorReturn(err)
+	}
+
+	return PurgeDeletedUserResponse{
+		Total:       totalAdmin + totalMobile,
+		DeletedFrom: before,
+	}, nil
+}
+
+func (u Usecase) validateRestore(deletedAt *time.Time) error {
+	if deletedAt == nil {
+		return localerror.InvalidData(constant2.UserNotDeleted.String())
+	}
+
+	if deletedAt.Before(u.retentionStart()) {
+		return localerror.InvalidDataWithData(constant2.RestoreWindowExpired.String(), map[string]string{
+			"days": strconv.Itoa(u.retentionDays()),
+		})
+	}
+
+	return nil
+}
+
+func (u Usecase) retentionDays() int {
+	return u.Env.GetInt("ACCOUNT_RETENTION_DAYS", constant.DefaultAccountRetentionDays)
+}
+
+func (u Usecase) retentionStart() time.Time {
+	return u.Clock.NowUTC().AddDate(0, 0, -u.retentionDays())
 }
 
 func (u Usecase) UpsertUser(ctx context.Context, request CreateUserRequest, action int) error {
-	exist, err := u.userAdminRepo.IsExist(ctx, "email", request.Email)
+	exist, err := u.userAdminRepo.IsExistCondition(ctx, db.Query(
+		db.Equal(request.Email, "user_admins.email"),
+		db.When(action == ActionIsUpdateUser, db.NotEqual(request.ID, "user_admins.id")),
+	))
 	if err != nil {
 		return u.ErrHandler.ErrorReturn(err)
 	}
@@ -50,11 +187,20 @@ func (u Usecase) UpsertUser(ctx context.Context, request CreateUserRequest, acti
 		return localerror.InvalidDataError{Msg: "Email already exists"}
 	}
 
-	var user = domain.UserAdmin{
-		Email:    request.Email,
-		FullName: request.FullName,
-		RoleID:   request.RoleId,
+	var user domain.UserAdmin
+	if action == ActionIsUpdateUser {
+		user, err = u.userAdminRepo.FindOneByID(ctx, request.ID)
+		if err != nil {
+			err = localerror.NotFound(err, constant2.UserNotFound.String())
+			return u.ErrHandler.ErrorReturn(err)
+		}
+		// version the client read, stale version is rejected on update
+		user.SetVersion(request.Version)
 	}
+	user.Email = request.Email
+	user.FullName = request.FullName
+	user.RoleID = request.RoleId
+
 	var status bool
 	if request.StatusKey != "" {
 		status = request.StatusKey == domain.Active
@@ -63,34 +209,26 @@ func (u Usecase) UpsertUser(ctx context.Context, request CreateUserRequest, acti
 	}
 	user.SetIsVerified(status)
 
-	var encryptMessage string
-	if action == ActionIsUpdateUser && request.Password == "" {
-		d, err := u.userAdminRepo.FindOneByID(ctx, request.ID)
-		if err != nil {
-			err = localerror.NotFound(err, constant2.UserNotFound.String())
-			return u.ErrHandler.ErrorReturn(err)
+	if action == ActionIsCreateUser || request.Password != "" {
+		user.Password, err = u.Davinci.HashAndSalt([]byte(request.Password))
+		if errors.Is(err, davinci.ErrPasswordTooLong) {
+			return localerror.InvalidData(constant2.PasswordTooLong.String())
 		}
-		encryptMessage = d.Password
-	} else {
-		encryptMessage, err = u.Davinci.EncryptMessage([]byte(u.Env.Get("ENCRYPT_MESSAGE_PASSWORD")), []byte(request.Password))
 		if err != nil {
 			return u.ErrHandler.ErrorReturn(err)
 		}
 	}
 
-	user.Password = encryptMessage
 	userLogin := u.Security.GetUserContext(ctx)
 	switch action {
 	case ActionIsCreateUser:
-		user.SetCreated(userLogin.Email)
 		_, err = u.userAdminRepo.Store(ctx, user)
 		if err != nil {
 			return u.ErrHandler.ErrorReturn(err)
 		}
 		break
 	case ActionIsUpdateUser:
-		user.SetUpdated(userLogin.Email)
-		err = u.userAdminRepo.Update(ctx, user)
+		err = u.userAdminRepo.UpdateSelectedCols(ctx, user, "email", "full_name", "role_id", "is_active", "password")
 		if err != nil {
 			return u.ErrHandler.ErrorReturn(err)
 		}
@@ -204,5 +342,5 @@ func (u Usecase) DeleteAccount(ctx context.Context) error {
 		return err
 	}
 
-	return nil
+	return u.sessions.RevokeUserSessions(ctx, constant.ContextMobile, userLogin.ID)
 }
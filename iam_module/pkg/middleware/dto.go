@@ -1,5 +1,6 @@
 package middleware
 
-type ctxKey string
+import "base-be-golang/shared/payload"
 
-const AuthCodeContext = ctxKey("authCode")
+// AuthCodeContext same key as payload.AuthCodeContext, so pkg outside iam module can read the user
+const AuthCodeContext = payload.AuthCodeContext
@@ -8,13 +8,13 @@ import (
 	"base-be-golang/pkg/localerror"
 	"base-be-golang/pkg/localize"
 	"base-be-golang/pkg/logger"
+	"base-be-golang/pkg/tenant"
 	"base-be-golang/shared/payload"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -22,6 +22,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/constant"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/domain"
+	"github.com/rdhmuhammad/base-be-golang/iam-module/pkg/security"
 	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/exp/slices"
@@ -37,6 +38,7 @@ type Auth struct {
 	clock         clock.CLOCK
 	userRepo      db.GenericRepository[domain.User]
 	userAdminRepo db.GenericRepository[domain.UserAdmin]
+	tokens        security.Auth
 }
 
 func NewAuth(dbConn *gorm.DB, dbCache cache.DbClient) Auth {
@@ -47,13 +49,14 @@ func NewAuth(dbConn *gorm.DB, dbCache cache.DbClient) Auth {
 		clock:         clock.CLOCK{},
 		userRepo:      db.NewGenericeRepo(dbConn, domain.User{}),
 		userAdminRepo: db.NewGenericeRepo(dbConn, domain.UserAdmin{}),
+		tokens:        security.NewAuth(&dbCache),
 	}
 }
 
 func (receiver Auth) Authorize(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var authData = payload.UserData{}
-		authDataStr, ok := c.Get("authData")
+		authDataStr, ok := c.Get(string(AuthCodeContext))
 		if ok {
 			authData = authDataStr.(payload.UserData)
 		}
@@ -111,10 +114,9 @@ func (receiver Auth) GetSession(ctx context.Context, authCode string, sessionDat
 }
 
 func (receiver Auth) GetUserContext(ctx context.Context) payload.UserData {
-	value := ctx.Value(AuthCodeContext)
-	if value != nil {
-		logger.Debug("data catch from context => " + value.(string))
-		return value.(payload.UserData)
+	if value, ok := ctx.Value(AuthCodeContext).(payload.UserData); ok {
+		logger.Debug("data catch from context => " + value.UserId)
+		return value
 	}
 
 	logger.Debug("no data in context")
@@ -122,14 +124,14 @@ func (receiver Auth) GetUserContext(ctx context.Context) payload.UserData {
 }
 
 /*
-Validate user token, and attach token data to context
+Validate user token, and attach token data to context.
+The session of the token (jti) must still be in cache, token of a revoked session is rejected before it expire.
 */
 func (receiver Auth) Validate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		tokenStr := strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", -1)
-		secret := os.Getenv("SECRET")
-		token, err := receiver.parseToken(tokenStr, []byte(secret))
+		token, err := receiver.parseToken(tokenStr)
 		if err != nil {
 			response := payload.DefaultErrorResponseWithMessage(err.Error(), err)
 			c.JSON(http.StatusUnauthorized, response)
@@ -148,8 +150,29 @@ func (receiver Auth) Validate() gin.HandlerFunc {
 			return
 		}
 
+		if valid && !security.SameTenant(c.Request.Context(), userDataStruct.TenantID) {
+			response := payload.DefaultBadRequestResponse()
+			response.Message = receiver.localize.GetLocalized(userDataStruct.Lang, constant2.AccessNotAllowed.String())
+			c.JSON(http.StatusUnauthorized, response)
+			c.Abort()
+			return
+		}
+
 		if valid {
-			receiver.setUserActivity(userDataStruct)
+			userDataStruct.SessionID = tokenID(token)
+			err = receiver.tokens.CheckSession(c.Request.Context(), userDataStruct.SessionID)
+			if err != nil {
+				if !localerror.IsAccessNotAllowedUserNotFound(err) {
+					logger.Error(err)
+				}
+				response := payload.DefaultErrorResponse(err)
+				response.Message = receiver.localize.GetLocalized(userDataStruct.Lang, constant2.SessionExpired.String())
+				c.JSON(http.StatusUnauthorized, response)
+				c.Abort()
+				return
+			}
+
+			receiver.setUserActivity(tenant.With(db.WithoutAudit(context.Background()), userDataStruct.TenantID), userDataStruct)
 			tz := time.UTC
 			if userDataStruct.Timezone != "" {
 				tz, err = time.LoadLocation(userDataStruct.Timezone)
@@ -160,7 +183,7 @@ func (receiver Auth) Validate() gin.HandlerFunc {
 			userDataStruct.Tz = tz
 			c.Set(string(AuthCodeContext), userDataStruct)
 			olCtx := c.Request.Context()
-			newCtx := context.WithValue(olCtx, AuthCodeContext, userDataStruct)
+			newCtx := tenant.With(context.WithValue(olCtx, AuthCodeContext, userDataStruct), userDataStruct.TenantID)
 			c.Request = c.Request.WithContext(newCtx)
 			c.Next()
 			return
@@ -172,13 +195,14 @@ func (receiver Auth) Validate() gin.HandlerFunc {
 	}
 }
 
-func (receiver Auth) parseToken(tokenStr string, secret []byte) (*jwt.Token, error) {
+func (receiver Auth) parseToken(tokenStr string) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			logger.Error(fmt.Errorf("invalid token format"))
+		key, err := receiver.tokens.Keyfunc(token)
+		if err != nil {
+			logger.Error(fmt.Errorf("invalid token format: %w", err))
 			return nil, localerror.AccessControlError{Msg: constant2.AccessNotAllowed.String()}
 		}
-		return secret, nil
+		return key, nil
 	})
 	if err != nil {
 
@@ -211,26 +235,36 @@ func (receiver Auth) getAuthData(token *jwt.Token) (map[string]interface{}, bool
 	return authData, valid
 }
 
-func (receiver Auth) setUserActivity(authData payload.UserData) {
+// tokenID the jti claim, empty for token issued before session
+func tokenID(token *jwt.Token) string {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	id, _ := claims["jti"].(string)
+	return id
+}
+
+func (receiver Auth) setUserActivity(ctx context.Context, authData payload.UserData) {
 	if authData.RoleName == constant.RolesIsMobile {
 		var user domain.User
-		setActivity(authData, receiver.userRepo, &user)
+		setActivity(ctx, authData, receiver.userRepo, &user)
 		return
 	}
 	var user domain.UserAdmin
-	setActivity(authData, receiver.userAdminRepo, &user)
+	setActivity(ctx, authData, receiver.userAdminRepo, &user)
 	return
 }
 
 type userSelect struct {
-	id       uint   `gorm:"column:id" json:"id"`
-	authCode string `gorm:"column:auth_code" json:"authCode"`
+	ID       uint   `gorm:"column:id" json:"id"`
+	AuthCode string `gorm:"column:auth_code" json:"authCode"`
 }
 
-func setActivity[T schema.Tabler](authData payload.UserData, repo db.GenericRepository[T], user domain.UserEntityInterface) {
+func setActivity[T schema.Tabler](ctx context.Context, authData payload.UserData, repo db.GenericRepository[T], user *T) {
 	var usec userSelect
 	err := repo.FindOneByExpSelection(
-		context.Background(),
+		ctx,
 		&usec,
 		[]clause.Expression{db.Equal(authData.UserId, "auth_code")},
 	)
@@ -238,10 +272,13 @@ func setActivity[T schema.Tabler](authData payload.UserData, repo db.GenericRepo
 		return
 	}
 
-	user.SetID(usec.id)
-	user.SetLastActive(time.Now().UTC())
-	d := user.(T)
-	err = repo.UpdateSelectedCols(context.Background(), d, "last_active")
+	entity, ok := any(user).(domain.UserEntityInterface)
+	if !ok {
+		return
+	}
+	entity.SetID(usec.ID)
+	entity.SetLastActive(time.Now().UTC())
+	err = repo.UpdateSelectedCols(ctx, *user, "last_active")
 	if err != nil {
 		return
 	}
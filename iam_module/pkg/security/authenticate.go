@@ -3,7 +3,7 @@ package security
 import (
 	"base-be-golang/pkg/clock"
 	"base-be-golang/pkg/environment"
-	"os"
+	"context"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -12,34 +12,50 @@ import (
 type Auth struct {
 	clock clock.CLOCK
 	env   environment.ENV
+	cache TokenCache
+	keys  *KeySet
 }
 
-func NewAuth() Auth {
+// TokenCache server-side store of session and refresh token, satisfied by *cache.DbClient
+type TokenCache interface {
+	Get(ctx context.Context, key string) (string, error)
+	GetDel(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetXX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	Delete(ctx context.Context, keys ...string) error
+	SAdd(ctx context.Context, key string, expiration time.Duration, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SRem(ctx context.Context, key string, members ...string) error
+}
+
+func NewAuth(cache TokenCache) Auth {
 	return Auth{
 		clock: clock.Default(),
 		env:   environment.NewEnvironment(),
+		cache: cache,
+		keys:  DefaultKeySet(),
 	}
 }
 
+// AccessTokenTTL (env: ACCESS_TOKEN_MINUTES, default 15), keep it short, the client use the refresh token after
+func (receiver Auth) AccessTokenTTL() time.Duration {
+	return time.Minute * time.Duration(receiver.env.GetInt("ACCESS_TOKEN_MINUTES", 15))
+}
+
 /*
-GenerateSingleToken fo generating single expiration token
+GenerateSingleToken fo generating single expiration token, signed by the key set (see KeySet)
 */
 func (receiver Auth) GenerateSingleToken(claim SingleTokenClaim) (string, error) {
-	method := jwt.SigningMethodHS256
-	claim.ExpiresAt = jwt.NewNumericDate(receiver.clock.NowUTC().Add(time.Hour * time.Duration(receiver.env.GetInt("EXPIRED_TOKEN_JWT", 0))))
-	token := &jwt.Token{
-		Header: map[string]interface{}{
-			"typ": "JWT",
-			"alg": method.Alg(),
-		},
-		Claims: claim,
-		Method: method,
-	}
-	secret := []byte(os.Getenv("SECRET"))
-	tokenStr, err := token.SignedString(secret)
-	if err != nil {
+	claim.ExpiresAt = jwt.NewNumericDate(receiver.clock.NowUTC().Add(receiver.AccessTokenTTL()))
+	return receiver.keys.Sign(claim)
+}
 
-		return "", err
-	}
-	return tokenStr, nil
+// Keyfunc verification key of token for jwt.Parse
+func (receiver Auth) Keyfunc(token *jwt.Token) (interface{}, error) {
+	return receiver.keys.Keyfunc(token)
+}
+
+// JWKS public keys for other services to verify the access token
+func (receiver Auth) JWKS() JWKS {
+	return receiver.keys.JWKS()
 }
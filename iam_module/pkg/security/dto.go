@@ -18,8 +18,9 @@ var (
 	RoleAdmin = "admin"
 )
 
+// SingleTokenClaim the jti (RegisteredClaims.ID) is the Session ID, every access token of the session share it
 type SingleTokenClaim struct {
-	UserData
+	UserData `json:"userData"`
 	jwt.RegisteredClaims
 }
 
@@ -29,4 +30,5 @@ type UserData struct {
 	Timezone string `json:"timezone"`
 	Email    string `json:"email"`
 	RoleName string `json:"roleName"`
+	TenantID string `json:"tenantId,omitempty"`
 }
@@ -0,0 +1,262 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const minRSAKeyBits = 2048
+
+var (
+	defaultKeySet     *KeySet
+	defaultKeySetOnce sync.Once
+)
+
+// JWK public key in the JSON Web Key format (RFC 7517), RSA or Ed25519 only
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+	jwk     JWK
+}
+
+/*
+KeySet keys of the access token, loaded from PEM files (PKCS#8, PKCS#1 or PKIX):
+  - JWT_PRIVATE_KEY_FILE the signing key, RSA (RS256) or Ed25519 (EdDSA)
+  - JWT_PUBLIC_KEY_FILES comma separated keys still accepted for verification, previous signing key while rotating
+
+Every key is identified by its JWK thumbprint (RFC 7638), written as kid header of the token.
+Without JWT_PRIVATE_KEY_FILE token is signed with HS256 and SECRET, and no key is published.
+*/
+type KeySet struct {
+	signing *jwtKey
+	verify  map[string]*jwtKey
+	secret  []byte
+}
+
+// DefaultKeySet load the key set from env once, panic on invalid key file so misconfiguration fail at start
+func DefaultKeySet() *KeySet {
+	defaultKeySetOnce.Do(func() {
+		keySet, err := LoadKeySet(
+			os.Getenv("JWT_PRIVATE_KEY_FILE"),
+			strings.Split(os.Getenv("JWT_PUBLIC_KEY_FILES"), ","),
+			[]byte(os.Getenv("SECRET")),
+		)
+		if err != nil {
+			panic(fmt.Sprintf("panic at jwt keys: %s", err.Error()))
+		}
+		defaultKeySet = keySet
+	})
+	return defaultKeySet
+}
+
+func LoadKeySet(privateKeyFile string, publicKeyFiles []string, secret []byte) (*KeySet, error) {
+	keySet := &KeySet{verify: map[string]*jwtKey{}, secret: secret}
+	if strings.TrimSpace(privateKeyFile) == "" {
+		return keySet, nil
+	}
+
+	signing, err := readKeyFile(privateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("%s: signing key must be a private key", privateKeyFile)
+	}
+	keySet.signing = signing
+	keySet.verify[signing.id] = signing
+
+	for _, file := range publicKeyFiles {
+		if strings.TrimSpace(file) == "" {
+			continue
+		}
+		key, err := readKeyFile(strings.TrimSpace(file))
+		if err != nil {
+			return nil, err
+		}
+		keySet.verify[key.id] = key
+	}
+
+	return keySet, nil
+}
+
+// Sign the claims with the signing key and its kid
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+
+	token := jwt.NewWithClaims(k.signing.method, claims)
+	token.Header["kid"] = k.signing.id
+	return token.SignedString(k.signing.private)
+}
+
+/*
+Keyfunc for jwt.Parse, the key is chosen by kid and the alg of the token must be the alg of that key,
+so a token can not pick a weaker algorithm. HMAC is only accepted when no asymmetric key is configured.
+*/
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if k.signing == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return k.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for kid %q", token.Method.Alg(), kid)
+	}
+	return key.public, nil
+}
+
+// JWKS public part of every verification key, the signing key first
+func (k *KeySet) JWKS() JWKS {
+	var jwks = JWKS{Keys: make([]JWK, 0, len(k.verify))}
+	if k.signing == nil {
+		return jwks
+	}
+
+	jwks.Keys = append(jwks.Keys, k.signing.jwk)
+	for id, key := range k.verify {
+		if id != k.signing.id {
+			jwks.Keys = append(jwks.Keys, key.jwk)
+		}
+	}
+	return jwks
+}
+
+func readKeyFile(file string) (*jwtKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", file)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	key, err := newJWTKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return key, nil
+}
+
+func newJWTKey(parsed interface{}) (*jwtKey, error) {
+	var key = &jwtKey{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.public = public
+		key.jwk = JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.public = public
+		key.jwk = JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(public),
+		}
+	default:
+		return nil, errors.New("unsupported key type, use RSA or Ed25519")
+	}
+
+	id, err := thumbprint(key.jwk)
+	if err != nil {
+		return nil, err
+	}
+	key.id = id
+	key.jwk.Kid = id
+	key.jwk.Use = "sig"
+	key.jwk.Alg = key.method.Alg()
+	return key, nil
+}
+
+// thumbprint of the required members of jwk in lexicographic order (RFC 7638)
+func thumbprint(jwk JWK) (string, error) {
+	var members interface{}
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
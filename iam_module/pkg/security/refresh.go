@@ -0,0 +1,122 @@
+package security
+
+import (
+	"base-be-golang/pkg/cache"
+	"base-be-golang/pkg/localerror"
+	"base-be-golang/pkg/tenant"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
+)
+
+const (
+	cacheKeyRefreshToken   = "REFRESH_TOKEN_"
+	cacheKeyRefreshRotated = "REFRESH_ROTATED_"
+)
+
+/*
+RefreshToken server-side record of an opaque refresh token, only the sha256 of the token is stored.
+Every token rotated from one login share SessionID, revoking the Session stop all of them.
+*/
+type RefreshToken struct {
+	SessionID string    `json:"sessionId"`
+	UserID    uint      `json:"userId"`
+	Context   string    `json:"context"`
+	TenantID  string    `json:"tenantId"`
+	Timezone  string    `json:"timezone"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RefreshTokenTTL (env: REFRESH_TOKEN_DAYS, default 30), every rotation start it again
+func (receiver Auth) RefreshTokenTTL() time.Duration {
+	return time.Hour * 24 * time.Duration(receiver.env.GetInt("REFRESH_TOKEN_DAYS", 30))
+}
+
+// IssueRefreshToken return a new token of the session of token, the session is extended with it
+func (receiver Auth) IssueRefreshToken(ctx context.Context, token RefreshToken) (string, RefreshToken, error) {
+	ctx = tenant.Without(ctx)
+	ttl := receiver.RefreshTokenTTL()
+
+	if _, err := receiver.extendSession(ctx, token.SessionID); err != nil {
+		return "", RefreshToken{}, err
+	}
+
+	raw, err := randomToken(32)
+	if err != nil {
+		return "", RefreshToken{}, err
+	}
+	token.ExpiresAt = receiver.clock.NowUTC().Add(ttl)
+	record, err := json.Marshal(token)
+	if err != nil {
+		return "", RefreshToken{}, err
+	}
+	err = receiver.cache.Set(ctx, cacheKeyRefreshToken+hashToken(raw), string(record), ttl)
+	if err != nil {
+		return "", RefreshToken{}, err
+	}
+
+	return raw, token, nil
+}
+
+/*
+RotateRefreshToken consume the token and return its record, the caller issue the next token of the same session.
+A token used for the second time revoke the whole session, one of the two callers hold a stolen token
+and there is no way to tell which, so both have to login again.
+*/
+func (receiver Auth) RotateRefreshToken(ctx context.Context, raw string) (RefreshToken, error) {
+	ctx = tenant.Without(ctx)
+	key := hashToken(raw)
+
+	record, err := receiver.cache.GetDel(ctx, cacheKeyRefreshToken+key)
+	if cache.IsMiss(err) {
+		sessionID, err := receiver.cache.Get(ctx, cacheKeyRefreshRotated+key)
+		if err == nil {
+			if err := receiver.RevokeSession(ctx, sessionID); err != nil {
+				return RefreshToken{}, err
+			}
+			return RefreshToken{}, localerror.AccessControlError{Msg: constant2.RefreshTokenReused.String()}
+		}
+		return RefreshToken{}, localerror.AccessControlError{Msg: constant2.SessionExpired.String()}
+	}
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	var token RefreshToken
+	err = json.Unmarshal([]byte(record), &token)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	// rotated token is remembered until it would have expired, to catch the reuse
+	err = receiver.cache.Set(ctx, cacheKeyRefreshRotated+key, token.SessionID, max(token.ExpiresAt.Sub(receiver.clock.NowUTC()), time.Second))
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	err = receiver.CheckSession(ctx, token.SessionID)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	return token, nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,206 @@
+package security
+
+import (
+	"base-be-golang/pkg/localerror"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/exp/slices"
+)
+
+// memoryCache in-memory TokenCache, a miss answer redis.Nil like *cache.DbClient
+type memoryCache struct {
+	kv   map[string]string
+	sets map[string][]string
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{kv: map[string]string{}, sets: map[string][]string{}}
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) (string, error) {
+	val, ok := m.kv[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return val, nil
+}
+
+func (m *memoryCache) GetDel(ctx context.Context, key string) (string, error) {
+	val, err := m.Get(ctx, key)
+	delete(m.kv, key)
+	return val, err
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	m.kv[key] = value.(string)
+	return nil
+}
+
+func (m *memoryCache) SetXX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	if _, ok := m.kv[key]; !ok {
+		return false, nil
+	}
+	m.kv[key] = value.(string)
+	return true, nil
+}
+
+func (m *memoryCache) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(m.kv, key)
+		delete(m.sets, key)
+	}
+	return nil
+}
+
+func (m *memoryCache) SAdd(ctx context.Context, key string, expiration time.Duration, members ...string) error {
+	for _, member := range members {
+		if !slices.Contains(m.sets[key], member) {
+			m.sets[key] = append(m.sets[key], member)
+		}
+	}
+	return nil
+}
+
+func (m *memoryCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	return m.sets[key], nil
+}
+
+func (m *memoryCache) SRem(ctx context.Context, key string, members ...string) error {
+	m.sets[key] = slices.DeleteFunc(m.sets[key], func(member string) bool {
+		return slices.Contains(members, member)
+	})
+	return nil
+}
+
+func newTestAuth(t *testing.T) (Auth, *memoryCache) {
+	t.Setenv("REFRESH_TOKEN_DAYS", "30")
+	store := newMemoryCache()
+	return NewAuth(store), store
+}
+
+// login start a session and issue its first refresh token
+func login(t *testing.T, auth Auth) (Session, string) {
+	ctx := context.Background()
+	session, err := auth.StartSession(ctx, Session{UserID: 7, Context: "MOBILE", TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	raw, _, err := auth.IssueRefreshToken(ctx, RefreshToken{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		Context:   session.Context,
+		TenantID:  session.TenantID,
+	})
+	if err != nil {
+		t.Fatalf("issue refresh token: %v", err)
+	}
+	return session, raw
+}
+
+func assertAccessError(t *testing.T, err error, msg constant2.ResponseMessage) {
+	t.Helper()
+	var accessErr localerror.AccessControlError
+	if !errors.As(err, &accessErr) || accessErr.Msg != msg.String() {
+		t.Fatalf("error = %v, want %s", err, msg)
+	}
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	auth, store := newTestAuth(t)
+	ctx := context.Background()
+	session, raw := login(t, auth)
+
+	for key := range store.kv {
+		if strings.Contains(key, raw) {
+			t.Fatalf("raw token is stored in key %s", key)
+		}
+	}
+
+	token, err := auth.RotateRefreshToken(ctx, raw)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if token.SessionID != session.ID || token.UserID != 7 || token.TenantID != "acme" {
+		t.Fatalf("token = %+v, want session %s of user 7 in acme", token, session.ID)
+	}
+
+	next, _, err := auth.IssueRefreshToken(ctx, token)
+	if err != nil {
+		t.Fatalf("issue next: %v", err)
+	}
+	if next == raw {
+		t.Fatal("next token is the rotated one")
+	}
+
+	token, err = auth.RotateRefreshToken(ctx, next)
+	if err != nil {
+		t.Fatalf("rotate next: %v", err)
+	}
+	if token.SessionID != session.ID {
+		t.Fatalf("session = %s, want %s", token.SessionID, session.ID)
+	}
+	if err := auth.CheckSession(ctx, session.ID); err != nil {
+		t.Fatalf("session ended by rotation: %v", err)
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokeSession(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	ctx := context.Background()
+	session, raw := login(t, auth)
+	other, otherRaw := login(t, auth)
+
+	token, err := auth.RotateRefreshToken(ctx, raw)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	next, _, err := auth.IssueRefreshToken(ctx, token)
+	if err != nil {
+		t.Fatalf("issue next: %v", err)
+	}
+
+	_, err = auth.RotateRefreshToken(ctx, raw)
+	assertAccessError(t, err, constant2.RefreshTokenReused)
+
+	assertAccessError(t, auth.CheckSession(ctx, session.ID), constant2.SessionExpired)
+	_, err = auth.RotateRefreshToken(ctx, next)
+	assertAccessError(t, err, constant2.SessionExpired)
+
+	// other device of the same user keep its session
+	if err := auth.CheckSession(ctx, other.ID); err != nil {
+		t.Fatalf("other session revoked: %v", err)
+	}
+	if _, err := auth.RotateRefreshToken(ctx, otherRaw); err != nil {
+		t.Fatalf("rotate other: %v", err)
+	}
+}
+
+func TestRotateRefreshTokenUnknown(t *testing.T) {
+	auth, _ := newTestAuth(t)
+
+	_, err := auth.RotateRefreshToken(context.Background(), "unknown")
+	assertAccessError(t, err, constant2.SessionExpired)
+}
+
+func TestIssueRefreshTokenRevokedSession(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	ctx := context.Background()
+	session, raw := login(t, auth)
+
+	token, err := auth.RotateRefreshToken(ctx, raw)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if err := auth.RevokeSession(ctx, session.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	_, _, err = auth.IssueRefreshToken(ctx, token)
+	assertAccessError(t, err, constant2.SessionExpired)
+}
@@ -0,0 +1,125 @@
+package security
+
+import (
+	"base-be-golang/pkg/cache"
+	"base-be-golang/pkg/localerror"
+	"base-be-golang/pkg/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
+)
+
+const (
+	cacheKeyResetPassword     = "RESET_PASSWORD_"
+	cacheKeyResetPasswordUser = "RESET_PASSWORD_USER_"
+
+	resetPasswordCooldown = time.Minute
+)
+
+/*
+ResetToken server-side record of a password reset token, only the sha256 of the token is stored.
+A user has one reset token at a time, it works once.
+*/
+type ResetToken struct {
+	UserID   uint      `json:"userId"`
+	Context  string    `json:"context"`
+	TenantID string    `json:"tenantId"`
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+type resetTokenUser struct {
+	Hash     string    `json:"hash"`
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+// ResetTokenTTL (env: RESET_PASSWORD_MINUTES, default 30)
+func (receiver Auth) ResetTokenTTL() time.Duration {
+	return time.Minute * time.Duration(receiver.env.GetInt("RESET_PASSWORD_MINUTES", 30))
+}
+
+/*
+IssueResetToken return a new reset token of the user, the previous one stop working.
+Token is empty when the previous one was issued less than a minute ago, so the email is not sent again and again.
+*/
+func (receiver Auth) IssueResetToken(ctx context.Context, token ResetToken) (string, error) {
+	ctx = tenant.Without(ctx)
+	ttl := receiver.ResetTokenTTL()
+	now := receiver.clock.NowUTC()
+	userKey := resetTokenUserKey(token.Context, token.UserID)
+
+	record, err := receiver.cache.Get(ctx, userKey)
+	if err != nil && !cache.IsMiss(err) {
+		return "", err
+	}
+	if err == nil {
+		var previous resetTokenUser
+		err = json.Unmarshal([]byte(record), &previous)
+		if err != nil {
+			return "", err
+		}
+		if now.Sub(previous.IssuedAt) < resetPasswordCooldown {
+			return "", nil
+		}
+		err = receiver.cache.Delete(ctx, cacheKeyResetPassword+previous.Hash)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	raw, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	token.IssuedAt = now
+	tokenRecord, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	err = receiver.cache.Set(ctx, cacheKeyResetPassword+hashToken(raw), string(tokenRecord), ttl)
+	if err != nil {
+		return "", err
+	}
+
+	userRecord, err := json.Marshal(resetTokenUser{Hash: hashToken(raw), IssuedAt: now})
+	if err != nil {
+		return "", err
+	}
+	err = receiver.cache.Set(ctx, userKey, string(userRecord), ttl)
+	if err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+// ConsumeResetToken return the record of the token and remove it, InvalidDataError when it is used or expired
+func (receiver Auth) ConsumeResetToken(ctx context.Context, raw string) (ResetToken, error) {
+	ctx = tenant.Without(ctx)
+
+	record, err := receiver.cache.GetDel(ctx, cacheKeyResetPassword+hashToken(raw))
+	if cache.IsMiss(err) {
+		return ResetToken{}, localerror.InvalidData(constant2.ResetTokenInvalid.String())
+	}
+	if err != nil {
+		return ResetToken{}, err
+	}
+
+	var token ResetToken
+	err = json.Unmarshal([]byte(record), &token)
+	if err != nil {
+		return ResetToken{}, err
+	}
+
+	err = receiver.cache.Delete(ctx, resetTokenUserKey(token.Context, token.UserID))
+	if err != nil {
+		return ResetToken{}, err
+	}
+	return token, nil
+}
+
+func resetTokenUserKey(userContext string, userID uint) string {
+	return fmt.Sprintf("%s%s_%d", cacheKeyResetPasswordUser, userContext, userID)
+}
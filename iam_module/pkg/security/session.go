@@ -0,0 +1,226 @@
+package security
+
+import (
+	"base-be-golang/pkg/cache"
+	"base-be-golang/pkg/localerror"
+	"base-be-golang/pkg/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
+)
+
+const (
+	cacheKeySession      = "SESSION_"
+	cacheKeyUserSessions = "USER_SESSIONS_"
+
+	maxUserAgentLength = 256
+)
+
+/*
+Session login of one device, its ID is the jti of every access token and the SessionID of every refresh token
+issued to that device. Validate reject token of a session that is gone, so revoking is immediate.
+Sessions of a user are indexed per Context (mobile or dashboard) and UserID, see UserSessions.
+*/
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     uint      `json:"userId"`
+	Context    string    `json:"context"`
+	TenantID   string    `json:"tenantId"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// StartSession store a new session of the device, it last as long as the refresh token
+func (receiver Auth) StartSession(ctx context.Context, session Session) (Session, error) {
+	ctx = tenant.Without(ctx)
+	ttl := receiver.RefreshTokenTTL()
+
+	id, err := randomToken(16)
+	if err != nil {
+		return Session{}, err
+	}
+	now := receiver.clock.NowUTC()
+	session.ID = id
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(ttl)
+	if len(session.UserAgent) > maxUserAgentLength {
+		session.UserAgent = session.UserAgent[:maxUserAgentLength]
+	}
+
+	record, err := json.Marshal(session)
+	if err != nil {
+		return Session{}, err
+	}
+	err = receiver.cache.Set(ctx, cacheKeySession+session.ID, string(record), ttl)
+	if err != nil {
+		return Session{}, err
+	}
+	err = receiver.cache.SAdd(ctx, userSessionsKey(session.Context, session.UserID), ttl, session.ID)
+	if err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+// CheckSession AccessControlError when the session is revoked or expired
+func (receiver Auth) CheckSession(ctx context.Context, id string) error {
+	_, err := receiver.FindSession(ctx, id)
+	return err
+}
+
+// FindSession AccessControlError when the session is revoked or expired
+func (receiver Auth) FindSession(ctx context.Context, id string) (Session, error) {
+	if id == "" {
+		return Session{}, localerror.AccessControlError{Msg: constant2.SessionExpired.String()}
+	}
+
+	record, err := receiver.cache.Get(tenant.Without(ctx), cacheKeySession+id)
+	if cache.IsMiss(err) {
+		return Session{}, localerror.AccessControlError{Msg: constant2.SessionExpired.String()}
+	}
+	if err != nil {
+		return Session{}, err
+	}
+
+	var session Session
+	err = json.Unmarshal([]byte(record), &session)
+	if err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// UserSessions active sessions of the user, last seen first
+func (receiver Auth) UserSessions(ctx context.Context, userContext string, userID uint) ([]Session, error) {
+	ctx = tenant.Without(ctx)
+	key := userSessionsKey(userContext, userID)
+
+	ids, err := receiver.cache.SMembers(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		sessions = make([]Session, 0, len(ids))
+		gone     []string
+	)
+	for _, id := range ids {
+		session, err := receiver.FindSession(ctx, id)
+		if localerror.IsAccessNotAllowedUserNotFound(err) {
+			gone = append(gone, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	// index outlive the session that expired by itself, drop them on read
+	if len(gone) > 0 {
+		err = receiver.cache.SRem(ctx, key, gone...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession end the session of one device, its access and refresh token stop working
+func (receiver Auth) RevokeSession(ctx context.Context, id string) error {
+	ctx = tenant.Without(ctx)
+
+	session, err := receiver.FindSession(ctx, id)
+	if localerror.IsAccessNotAllowedUserNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = receiver.cache.Delete(ctx, cacheKeySession+session.ID)
+	if err != nil {
+		return err
+	}
+	return receiver.cache.SRem(ctx, userSessionsKey(session.Context, session.UserID), session.ID)
+}
+
+// RevokeUserSessions end every session of the user except keep, log out everywhere
+func (receiver Auth) RevokeUserSessions(ctx context.Context, userContext string, userID uint, keep ...string) error {
+	ctx = tenant.Without(ctx)
+	key := userSessionsKey(userContext, userID)
+
+	ids, err := receiver.cache.SMembers(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	var revoked, keys []string
+	for _, id := range ids {
+		if !slices.Contains(keep, id) {
+			revoked = append(revoked, id)
+			keys = append(keys, cacheKeySession+id)
+		}
+	}
+	if len(keep) == 0 {
+		return receiver.cache.Delete(ctx, append(keys, key)...)
+	}
+	if len(revoked) == 0 {
+		return nil
+	}
+
+	err = receiver.cache.Delete(ctx, keys...)
+	if err != nil {
+		return err
+	}
+	return receiver.cache.SRem(ctx, key, revoked...)
+}
+
+// extendSession mark the session as seen now and restart its expiration, it is never brought back once revoked
+func (receiver Auth) extendSession(ctx context.Context, id string) (Session, error) {
+	ttl := receiver.RefreshTokenTTL()
+
+	session, err := receiver.FindSession(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	now := receiver.clock.NowUTC()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(ttl)
+
+	record, err := json.Marshal(session)
+	if err != nil {
+		return Session{}, err
+	}
+	ok, err := receiver.cache.SetXX(ctx, cacheKeySession+session.ID, string(record), ttl)
+	if err != nil {
+		return Session{}, err
+	}
+	if !ok {
+		return Session{}, localerror.AccessControlError{Msg: constant2.SessionExpired.String()}
+	}
+
+	err = receiver.cache.SAdd(ctx, userSessionsKey(session.Context, session.UserID), ttl, session.ID)
+	if err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func userSessionsKey(userContext string, userID uint) string {
+	return fmt.Sprintf("%s%s_%d", cacheKeyUserSessions, userContext, userID)
+}
@@ -0,0 +1,16 @@
+package security
+
+import (
+	"base-be-golang/pkg/tenant"
+	"context"
+)
+
+/*
+SameTenant false when ctx carries a tenant other than tenantID.
+Session, refresh token and reset token are stored without tenant namespace and carry TenantID instead,
+so every credential is checked here: one issued to a tenant can not be used with the header of another.
+*/
+func SameTenant(ctx context.Context, tenantID string) bool {
+	id, ok := tenant.FromContext(ctx)
+	return !ok || id == tenantID
+}
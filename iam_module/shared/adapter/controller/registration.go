@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/constant"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/usecase/registration"
+	"github.com/rdhmuhammad/base-be-golang/iam-module/pkg/security"
 	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
 	"gorm.io/gorm"
 )
@@ -28,17 +29,39 @@ func NewAuthController(dbConn *gorm.DB, port base.Port, controller base.BaseCont
 
 type AuthUsecaseInterface interface {
 	Register(ctx context.Context, request registration.RegisterRequest) (registration.RegisterResponse, error)
-	Logout(ctx context.Context, role string) error
+	Logout(ctx context.Context) error
+	LogoutAll(ctx context.Context) error
+	ListSessions(ctx context.Context) ([]registration.SessionItem, error)
+	RevokeSession(ctx context.Context, id string) error
 	Login(ctx context.Context, request registration.LoginRequest) (registration.LoginResponse, error)
+	Refresh(ctx context.Context, request registration.RefreshRequest) (registration.LoginResponse, error)
 	VerifyAcc(ctx context.Context, request registration.VerifyAccRequest) (registration.VerifyAccResponse, error)
 	ResendOTP(ctx context.Context, request registration.SendOtpRequest) error
+	ForgotPassword(ctx context.Context, request registration.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, request registration.ResetPasswordRequest) error
+	ChangePassword(ctx context.Context, request registration.ChangePasswordRequest) error
 }
 
-func (ctrl AuthController) Logout(c *gin.Context, role string) {
-	err := ctrl.uc.Logout(c.Request.Context(), role)
+func (ctrl AuthController) Logout(c *gin.Context) {
+	err := ctrl.uc.Logout(c.Request.Context())
 	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant2.LogoutSuccess.String()), err)
 }
 
+func (ctrl AuthController) LogoutAll(c *gin.Context) {
+	err := ctrl.uc.LogoutAll(c.Request.Context())
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant2.LogoutAllSuccess.String()), err)
+}
+
+func (ctrl AuthController) ListSessions(c *gin.Context) {
+	result, err := ctrl.uc.ListSessions(c.Request.Context())
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant2.GetListSession.String()), err)
+}
+
+func (ctrl AuthController) RevokeSession(c *gin.Context) {
+	err := ctrl.uc.RevokeSession(c.Request.Context(), c.Param("sessionId"))
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant2.RevokeSession.String()), err)
+}
+
 func (ctrl AuthController) Login(c *gin.Context, role string) {
 	var request registration.LoginRequest
 	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
@@ -46,10 +69,23 @@ func (ctrl AuthController) Login(c *gin.Context, role string) {
 		return
 	}
 	request.Role = role
+	request.UserAgent = c.Request.UserAgent()
+	request.IP = c.ClientIP()
 	result, err := ctrl.uc.Login(c.Request.Context(), request)
 	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant2.LoginSuccess.String()), err)
 }
 
+func (ctrl AuthController) Refresh(c *gin.Context) {
+	var request registration.RefreshRequest
+	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	result, err := ctrl.uc.Refresh(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant2.RefreshSuccess.String()), err)
+}
+
 func (ctrl AuthController) Register(c *gin.Context) {
 	var request registration.RegisterRequest
 	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
@@ -82,6 +118,50 @@ func (ctrl AuthController) ResendOTP(c *gin.Context) {
 	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant2.ResendOtpSuccess.String()), err)
 }
 
+func (ctrl AuthController) ForgotPassword(c *gin.Context, role string) {
+	var request registration.ForgotPasswordRequest
+	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+	request.Role = role
+
+	err := ctrl.uc.ForgotPassword(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant2.ForgotPasswordSuccess.String()), err)
+}
+
+func (ctrl AuthController) ResetPassword(c *gin.Context) {
+	var request registration.ResetPasswordRequest
+	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	err := ctrl.uc.ResetPassword(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant2.ResetPasswordSuccess.String()), err)
+}
+
+func (ctrl AuthController) ChangePassword(c *gin.Context) {
+	var request registration.ChangePasswordRequest
+	if errs := ctrl.Enigma.BindAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	err := ctrl.uc.ChangePassword(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant2.ChangePasswordSuccess.String()), err)
+}
+
+// JWKS public keys of the access token, answered as plain JWK Set so any JWT library can read it
+func (ctrl AuthController) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, security.DefaultKeySet().JWKS())
+}
+
+func (ctrl AuthController) RouteWellKnown(router *gin.RouterGroup) {
+	router.GET("/jwks.json", ctrl.JWKS)
+}
+
 func (ctrl AuthController) Route(router *gin.RouterGroup) {
 	userAuth := router.Group("/auth")
 	userAuth.POST("/register",
@@ -105,19 +185,33 @@ func (ctrl AuthController) Route(router *gin.RouterGroup) {
 		},
 	)
 
-	userAuth.POST("/logout",
-		ctrl.Security.Validate(),
-		ctrl.Security.Authorize(constant.RoleIsAdmin, constant.RoleIsUser),
+	// access token may be expired already, the refresh token is the credential
+	userAuth.POST("/refresh", ctrl.Refresh)
+
+	// logout end the session of the token, both path are kept for older client
+	userAuth.POST("/logout", ctrl.Security.Validate(), ctrl.Logout)
+	userAuth.POST("/logout/admin", ctrl.Security.Validate(), ctrl.Logout)
+	userAuth.POST("/logout/all", ctrl.Security.Validate(), ctrl.LogoutAll)
+
+	userAuth.POST("/forgot-password",
 		func(c *gin.Context) {
-			ctrl.Logout(c, constant.ContextMobile)
-		})
+			ctrl.ForgotPassword(c, constant.ContextMobile)
+		},
+	)
 
-	userAuth.POST("/logout/admin",
-		ctrl.Security.Validate(),
-		ctrl.Security.Authorize(constant.RolesIsMobile),
+	userAuth.POST("/forgot-password/admin",
 		func(c *gin.Context) {
-			ctrl.Logout(c, constant.ContextDashboard)
-		})
+			ctrl.ForgotPassword(c, constant.ContextDashboard)
+		},
+	)
+
+	// the reset token carries the user, one path for mobile and admin
+	userAuth.POST("/reset-password", ctrl.ResetPassword)
+	userAuth.POST("/change-password", ctrl.Security.Validate(), ctrl.ChangePassword)
+
+	sessions := userAuth.Group("/sessions", ctrl.Security.Validate())
+	sessions.GET("", ctrl.ListSessions)
+	sessions.DELETE("/:sessionId", ctrl.RevokeSession)
 
 	userAuth.POST(
 		"/verify-acc",
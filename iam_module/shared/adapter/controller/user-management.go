@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/adapter/repository"
+	constant2 "github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/constant"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/domain"
 	user_management "github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/usecase/usermanagement"
 	"github.com/rdhmuhammad/base-be-golang/iam-module/shared/constant"
@@ -25,6 +26,11 @@ type UserManagementUsecase interface {
 	UpsertUser(ctx context.Context, request user_management.CreateUserRequest, action int) error
 	GetDetail(ctx context.Context, id uint) (user_management.UserDetailItem, error)
 	GetList(ctx context.Context, query repository.UserListQuery) (payload.PaginationResponse[domain.UserListItem], error)
+	RestoreUser(ctx context.Context, id uint) error
+	RestoreAccount(ctx context.Context, id uint) error
+	ForceLogout(ctx context.Context, id uint) error
+	ForceLogoutAccount(ctx context.Context, id uint) error
+	PurgeDeletedUser(ctx context.Context) (user_management.PurgeDeletedUserResponse, error)
 }
 
 func NewUserManagementController(dbConn *gorm.DB, port base.Port, controller base.BaseController) UserManagementController {
@@ -59,7 +65,7 @@ func (ctrl UserManagementController) UpdateUser(c *gin.Context) {
 	}
 
 	request.ID = uint(userId)
-	err = ctrl.uc.UpsertUser(c.Request.Context(), request, user_management.ActionIsCreateUser)
+	err = ctrl.uc.UpsertUser(c.Request.Context(), request, user_management.ActionIsUpdateUser)
 	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.UpdateUser.String()), err)
 }
 
@@ -93,13 +99,85 @@ func (ctrl UserManagementController) GetListUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
 		return
 	}
+	query, errs := ctrl.Enigma.BindFilter(c, repository.UserListFilter)
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+	request.Query = query
 
 	request.Filter.SetIfEmpty()
 	result, err := ctrl.uc.GetList(c.Request.Context(), request)
 	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant.GetListUser.String()), err)
 }
 
+func (ctrl UserManagementController) RestoreUser(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage(err.Error()))
+		return
+	}
+
+	err = ctrl.uc.RestoreUser(c.Request.Context(), uint(userId))
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.RestoreUser.String()), err)
+}
+
+func (ctrl UserManagementController) RestoreAccount(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage(err.Error()))
+		return
+	}
+
+	err = ctrl.uc.RestoreAccount(c.Request.Context(), uint(userId))
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.RestoreUser.String()), err)
+}
+
+func (ctrl UserManagementController) ForceLogout(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage(err.Error()))
+		return
+	}
+
+	err = ctrl.uc.ForceLogout(c.Request.Context(), uint(userId))
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.ForceLogout.String()), err)
+}
+
+func (ctrl UserManagementController) ForceLogoutAccount(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage(err.Error()))
+		return
+	}
+
+	err = ctrl.uc.ForceLogoutAccount(c.Request.Context(), uint(userId))
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponseNoData(constant.ForceLogout.String()), err)
+}
+
+func (ctrl UserManagementController) PurgeDeletedUser(c *gin.Context) {
+	result, err := ctrl.uc.PurgeDeletedUser(c.Request.Context())
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant.PurgeDeletedUser.String()), err)
+}
+
 func (ctrl UserManagementController) Route(handler *gin.RouterGroup) {
-	//TODO implement me
-	panic("implement me")
+	users := handler.Group("/users",
+		ctrl.Security.Validate(),
+		ctrl.Security.Authorize(constant2.RoleIsAdmin),
+	)
+
+	users.GET("", ctrl.GetListUser)
+	users.POST("", ctrl.CreateUser)
+	users.GET("/:userId", ctrl.GetDetailUser)
+	users.PUT("/:userId", ctrl.UpdateUser)
+	users.DELETE("/:userId", ctrl.DeleteUser)
+
+	// soft deleted user, restore within retention window
+	users.PUT("/:userId/restore", ctrl.RestoreUser)
+	users.PUT("/mobile/:userId/restore", ctrl.RestoreAccount)
+	users.DELETE("/trash", ctrl.PurgeDeletedUser)
+
+	// end every session of the user on all devices
+	users.POST("/:userId/force-logout", ctrl.ForceLogout)
+	users.POST("/mobile/:userId/force-logout", ctrl.ForceLogoutAccount)
 }
@@ -13,6 +13,7 @@ const (
 	UserAlreadyVerified
 	AccessNotAllowed
 	SessionExpired
+	DataConflict
 
 	// registration
 	LogoutSuccess
@@ -21,6 +22,19 @@ const (
 	VerifyOtpSuccess
 	ResendOtpSuccess
 	UserNotFound
+	RefreshSuccess
+	RefreshTokenReused
+	GetListSession
+	RevokeSession
+	LogoutAllSuccess
+	SessionNotFound
+	PasswordTooLong
+	ForgotPasswordSuccess
+	ResetPasswordSuccess
+	ChangePasswordSuccess
+	ResetTokenInvalid
+	CurrentPasswordMismatch
+	TenantRequired
 
 	// user-management
 	CreateUser
@@ -28,4 +42,9 @@ const (
 	DeleteUser
 	GetDetailUser
 	GetListUser
+	RestoreUser
+	PurgeDeletedUser
+	UserNotDeleted
+	RestoreWindowExpired
+	ForceLogout
 )
@@ -16,22 +16,41 @@ func _() {
 	_ = x[UserAlreadyVerified-5]
 	_ = x[AccessNotAllowed-6]
 	_ = x[SessionExpired-7]
-	_ = x[LogoutSuccess-8]
-	_ = x[LoginSuccess-9]
-	_ = x[RegisterSuccess-10]
-	_ = x[VerifyOtpSuccess-11]
-	_ = x[ResendOtpSuccess-12]
-	_ = x[UserNotFound-13]
-	_ = x[CreateUser-14]
-	_ = x[UpdateUser-15]
-	_ = x[DeleteUser-16]
-	_ = x[GetDetailUser-17]
-	_ = x[GetListUser-18]
+	_ = x[DataConflict-8]
+	_ = x[LogoutSuccess-9]
+	_ = x[LoginSuccess-10]
+	_ = x[RegisterSuccess-11]
+	_ = x[VerifyOtpSuccess-12]
+	_ = x[ResendOtpSuccess-13]
+	_ = x[UserNotFound-14]
+	_ = x[RefreshSuccess-15]
+	_ = x[RefreshTokenReused-16]
+	_ = x[GetListSession-17]
+	_ = x[RevokeSession-18]
+	_ = x[LogoutAllSuccess-19]
+	_ = x[SessionNotFound-20]
+	_ = x[PasswordTooLong-21]
+	_ = x[ForgotPasswordSuccess-22]
+	_ = x[ResetPasswordSuccess-23]
+	_ = x[ChangePasswordSuccess-24]
+	_ = x[ResetTokenInvalid-25]
+	_ = x[CurrentPasswordMismatch-26]
+	_ = x[TenantRequired-27]
+	_ = x[CreateUser-28]
+	_ = x[UpdateUser-29]
+	_ = x[DeleteUser-30]
+	_ = x[GetDetailUser-31]
+	_ = x[GetListUser-32]
+	_ = x[RestoreUser-33]
+	_ = x[PurgeDeletedUser-34]
+	_ = x[UserNotDeleted-35]
+	_ = x[RestoreWindowExpired-36]
+	_ = x[ForceLogout-37]
 }
 
-const _ResponseMessage_name = "LoginPasswordMismatchLoginUnverifiedRegisterEmailUsedEmailNotFoundVerifyOtpExpiredUserAlreadyVerifiedAccessNotAllowedSessionExpiredLogoutSuccessLoginSuccessRegisterSuccessVerifyOtpSuccessResendOtpSuccessUserNotFoundCreateUserUpdateUserDeleteUserGetDetailUserGetListUser"
+const _ResponseMessage_name = "LoginPasswordMismatchLoginUnverifiedRegisterEmailUsedEmailNotFoundVerifyOtpExpiredUserAlreadyVerifiedAccessNotAllowedSessionExpiredDataConflictLogoutSuccessLoginSuccessRegisterSuccessVerifyOtpSuccessResendOtpSuccessUserNotFoundRefreshSuccessRefreshTokenReusedGetListSessionRevokeSessionLogoutAllSuccessSessionNotFoundPasswordTooLongForgotPasswordSuccessResetPasswordSuccessChangePasswordSuccessResetTokenInvalidCurrentPasswordMismatchTenantRequiredCreateUserUpdateUserDeleteUserGetDetailUserGetListUserRestoreUserPurgeDeletedUserUserNotDeletedRestoreWindowExpiredForceLogout"
 
-var _ResponseMessage_index = [...]uint16{0, 21, 36, 53, 66, 82, 101, 117, 131, 144, 156, 171, 187, 203, 215, 225, 235, 245, 258, 269}
+var _ResponseMessage_index = [...]uint16{0, 21, 36, 53, 66, 82, 101, 117, 131, 143, 156, 168, 183, 199, 215, 227, 241, 259, 273, 286, 302, 317, 332, 353, 373, 394, 411, 434, 448, 458, 468, 478, 491, 502, 513, 529, 543, 563, 574}
 
 func (i ResponseMessage) String() string {
 	idx := int(i) - 0
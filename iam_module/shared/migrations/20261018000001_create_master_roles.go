@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018000001",
+		Name:    "create_master_roles",
+		Up: func(tx *gorm.DB) error {
+			// table of install older than the migrations, columns added later come with their own migration
+			if tx.Migrator().HasTable("master_roles") {
+				return nil
+			}
+			type masterRole struct {
+				ID        uint      `gorm:"primaryKey;autoIncrement"`
+				CreatedAt time.Time `gorm:"column:created_at"`
+				UpdatedAt time.Time `gorm:"column:updated_at"`
+				CreatedBy string    `gorm:"column:created_by;type:varchar(100)"`
+				UpdatedBy string    `gorm:"column:updated_by;type:varchar(100)"`
+				Name      string    `gorm:"column:name;type:varchar(50);uniqueIndex:uk_master_roles_name"`
+				Label     string    `gorm:"column:label;type:varchar(100)"`
+			}
+			return tx.Table("master_roles").Migrator().CreateTable(&masterRole{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("master_roles")
+		},
+	})
+}
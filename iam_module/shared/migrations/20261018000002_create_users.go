@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018000002",
+		Name:    "create_users",
+		Up: func(tx *gorm.DB) error {
+			// table of install older than the migrations, columns added later come with their own migration
+			if tx.Migrator().HasTable("users") {
+				return nil
+			}
+			type user struct {
+				ID         uint         `gorm:"primaryKey;autoIncrement"`
+				CreatedAt  time.Time    `gorm:"column:created_at"`
+				UpdatedAt  time.Time    `gorm:"column:updated_at"`
+				CreatedBy  string       `gorm:"column:created_by;type:varchar(100)"`
+				UpdatedBy  string       `gorm:"column:updated_by;type:varchar(100)"`
+				Code       string       `gorm:"column:code;type:varchar(50)"`
+				Profile    string       `gorm:"column:profile;type:varchar(255)"`
+				FullName   string       `gorm:"column:full_name;type:varchar(150)"`
+				Phone      string       `gorm:"column:phone;type:varchar(30)"`
+				Email      string       `gorm:"column:email;type:varchar(150);index:idx_users_email"`
+				Password   string       `gorm:"column:password;type:varchar(255)"`
+				IsVerified int32        `gorm:"column:is_verified;not null;default:0"`
+				OTPCode    int32        `gorm:"column:otp_code"`
+				AuthCode   string       `gorm:"column:auth_code;type:varchar(100);index:idx_users_auth_code"`
+				Lang       string       `gorm:"column:lang;type:varchar(10)"`
+				LastActive sql.NullTime `gorm:"column:last_active"`
+			}
+			return tx.Table("users").Migrator().CreateTable(&user{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("users")
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018000003",
+		Name:    "create_user_admins",
+		Up: func(tx *gorm.DB) error {
+			// table of install older than the migrations, columns added later come with their own migration
+			if tx.Migrator().HasTable("user_admins") {
+				return nil
+			}
+			type userAdmin struct {
+				ID         uint         `gorm:"primaryKey;autoIncrement"`
+				CreatedAt  time.Time    `gorm:"column:created_at"`
+				UpdatedAt  time.Time    `gorm:"column:updated_at"`
+				CreatedBy  string       `gorm:"column:created_by;type:varchar(100)"`
+				UpdatedBy  string       `gorm:"column:updated_by;type:varchar(100)"`
+				Code       string       `gorm:"column:code;type:varchar(50)"`
+				FullName   string       `gorm:"column:full_name;type:varchar(150)"`
+				Email      string       `gorm:"column:email;type:varchar(150);index:idx_user_admins_email"`
+				Phone      string       `gorm:"column:phone;type:varchar(30)"`
+				RoleID     uint         `gorm:"column:role_id;index:idx_user_admins_role_id"`
+				Password   string       `gorm:"column:password;type:varchar(255)"`
+				AuthCode   string       `gorm:"column:auth_code;type:varchar(100);index:idx_user_admins_auth_code"`
+				IsActive   int32        `gorm:"column:is_active;not null;default:0"`
+				LastActive sql.NullTime `gorm:"column:last_active"`
+			}
+			return tx.Table("user_admins").Migrator().CreateTable(&userAdmin{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("user_admins")
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+type userSoftDelete struct {
+	DeletedAt sql.NullTime `gorm:"column:deleted_at;index:idx_users_deleted_at"`
+	DeletedBy string       `gorm:"column:deleted_by;type:varchar(100)"`
+}
+
+type userAdminSoftDelete struct {
+	DeletedAt sql.NullTime `gorm:"column:deleted_at;index:idx_user_admins_deleted_at"`
+	DeletedBy string       `gorm:"column:deleted_by;type:varchar(100)"`
+}
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018000004",
+		Name:    "add_soft_delete_to_iam_users",
+		Up: func(tx *gorm.DB) error {
+			if err := addSoftDelete(tx, "users", &userSoftDelete{}, "idx_users_deleted_at"); err != nil {
+				return err
+			}
+			return addSoftDelete(tx, "user_admins", &userAdminSoftDelete{}, "idx_user_admins_deleted_at")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropSoftDelete(tx, "users", &userSoftDelete{}, "idx_users_deleted_at"); err != nil {
+				return err
+			}
+			return dropSoftDelete(tx, "user_admins", &userAdminSoftDelete{}, "idx_user_admins_deleted_at")
+		},
+	})
+}
+
+func addSoftDelete(tx *gorm.DB, table string, model interface{}, index string) error {
+	if err := addMissingColumns(tx, table, model, "DeletedAt", "DeletedBy"); err != nil {
+		return err
+	}
+	return createMissingIndex(tx, table, model, index)
+}
+
+func dropSoftDelete(tx *gorm.DB, table string, model interface{}, index string) error {
+	if err := tx.Table(table).Migrator().DropIndex(model, index); err != nil {
+		return err
+	}
+	return dropColumns(tx, table, model, "DeletedAt", "DeletedBy")
+}
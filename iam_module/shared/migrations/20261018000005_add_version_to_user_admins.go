@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+
+	"gorm.io/gorm"
+)
+
+type userAdminVersion struct {
+	Version uint `gorm:"column:version;not null;default:1"`
+}
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018000005",
+		Name:    "add_version_to_user_admins",
+		Up: func(tx *gorm.DB) error {
+			return addMissingColumns(tx, "user_admins", &userAdminVersion{}, "Version")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, "user_admins", &userAdminVersion{}, "Version")
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+
+	"gorm.io/gorm"
+)
+
+type userTenant struct {
+	TenantID string `gorm:"column:tenant_id;type:varchar(64);not null;default:'';index:idx_users_tenant_id_email,priority:1"`
+	Email    string `gorm:"column:email;type:varchar(150);index:idx_users_tenant_id_email,priority:2"`
+}
+
+type userAdminTenant struct {
+	TenantID string `gorm:"column:tenant_id;type:varchar(64);not null;default:'';index:idx_user_admins_tenant_id_email,priority:1"`
+	Email    string `gorm:"column:email;type:varchar(150);index:idx_user_admins_tenant_id_email,priority:2"`
+}
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018100000",
+		Name:    "add_tenant_id_to_iam_users",
+		Up: func(tx *gorm.DB) error {
+			if err := addTenantID(tx, "users", &userTenant{}, "idx_users_tenant_id_email"); err != nil {
+				return err
+			}
+			return addTenantID(tx, "user_admins", &userAdminTenant{}, "idx_user_admins_tenant_id_email")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropTenantID(tx, "users", &userTenant{}, "idx_users_tenant_id_email"); err != nil {
+				return err
+			}
+			return dropTenantID(tx, "user_admins", &userAdminTenant{}, "idx_user_admins_tenant_id_email")
+		},
+	})
+}
+
+func addTenantID(tx *gorm.DB, table string, model interface{}, index string) error {
+	migrator := tx.Table(table).Migrator()
+	if err := migrator.AddColumn(model, "TenantID"); err != nil {
+		return err
+	}
+	return migrator.CreateIndex(model, index)
+}
+
+func dropTenantID(tx *gorm.DB, table string, model interface{}, index string) error {
+	migrator := tx.Table(table).Migrator()
+	if err := migrator.DropIndex(model, index); err != nil {
+		return err
+	}
+	return migrator.DropColumn(model, "TenantID")
+}
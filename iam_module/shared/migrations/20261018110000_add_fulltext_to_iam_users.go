@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+
+	"gorm.io/gorm"
+)
+
+// FULLTEXT index is MySQL only, the other drivers use the local search engine
+type userFulltext struct {
+	FullName string `gorm:"column:full_name;index:idx_users_fulltext,class:FULLTEXT,priority:1"`
+	Email    string `gorm:"column:email;index:idx_users_fulltext,class:FULLTEXT,priority:2"`
+}
+
+type userAdminFulltext struct {
+	FullName string `gorm:"column:full_name;index:idx_user_admins_fulltext,class:FULLTEXT,priority:1"`
+	Email    string `gorm:"column:email;index:idx_user_admins_fulltext,class:FULLTEXT,priority:2"`
+}
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018110000",
+		Name:    "add_fulltext_to_iam_users",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if err := tx.Table("users").Migrator().CreateIndex(&userFulltext{}, "idx_users_fulltext"); err != nil {
+				return err
+			}
+			return tx.Table("user_admins").Migrator().CreateIndex(&userAdminFulltext{}, "idx_user_admins_fulltext")
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if err := tx.Table("users").Migrator().DropIndex(&userFulltext{}, "idx_users_fulltext"); err != nil {
+				return err
+			}
+			return tx.Table("user_admins").Migrator().DropIndex(&userAdminFulltext{}, "idx_user_admins_fulltext")
+		},
+	})
+}
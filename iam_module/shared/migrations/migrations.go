@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+
+	"gorm.io/gorm"
+)
+
+var migrations []migration.Migration
+
+// register is called by init of every migration file, create one with `go run ./cmd/migrate -dir iam_module/shared/migrations create <name>`
+func register(m migration.Migration) {
+	migrations = append(migrations, m)
+}
+
+// Source iam module migrations, included by cmd/migrate unless IAM_MODULE_OFF
+func Source() migration.Source {
+	return migration.Source{
+		Module:     "iam",
+		Migrations: migrations,
+	}
+}
+
+// addMissingColumns add field of model to table, column that already exist (created with the table) is left as is
+func addMissingColumns(tx *gorm.DB, table string, model interface{}, fields ...string) error {
+	migrator := tx.Table(table).Migrator()
+	for _, field := range fields {
+		if migrator.HasColumn(model, field) {
+			continue
+		}
+		if err := migrator.AddColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createMissingIndex(tx *gorm.DB, table string, model interface{}, index string) error {
+	migrator := tx.Table(table).Migrator()
+	if migrator.HasIndex(model, index) {
+		return nil
+	}
+	return migrator.CreateIndex(model, index)
+}
+
+func dropColumns(tx *gorm.DB, table string, model interface{}, fields ...string) error {
+	migrator := tx.Table(table).Migrator()
+	for _, field := range fields {
+		if err := migrator.DropColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package seeders
+
+import (
+	"base-be-golang/pkg/davinci"
+	"base-be-golang/pkg/db"
+	"base-be-golang/pkg/seed"
+	"base-be-golang/pkg/tenant"
+	"context"
+	"os"
+
+	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/constant"
+	"github.com/rdhmuhammad/base-be-golang/iam-module/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+// Source iam module seeders, included by cmd/seed unless IAM_MODULE_OFF
+func Source() seed.Source {
+	return seed.Source{
+		Module: "iam",
+		Seeders: []seed.Seeder{
+			{Name: "master_roles", Run: seedMasterRoles},
+			{Name: "first_admin", Run: seedFirstAdmin},
+		},
+	}
+}
+
+func seedMasterRoles(ctx context.Context, dbConn *gorm.DB) error {
+	repo := db.NewGenericeRepo(dbConn, domain.MasterRole{})
+
+	roles := []domain.MasterRole{
+		{Name: constant.RoleIsAdmin, Label: "Administrator"},
+	}
+	for _, role := range roles {
+		exist, err := repo.IsExist(ctx, "name", role.Name)
+		if err != nil {
+			return err
+		}
+		if exist {
+			continue
+		}
+
+		_, err = repo.Store(ctx, role)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+seedFirstAdmin create the admin able to login through /auth/login/admin
+(env: SEED_ADMIN_EMAIL, SEED_ADMIN_PASSWORD, SEED_ADMIN_NAME), skipped when the email is already used.
+SEED_ADMIN_TENANT create the admin of that tenant, rerun with another value for the next tenant.
+Without email or password it is skipped, so the other seeders still run, and fails only under -only first_admin.
+*/
+func seedFirstAdmin(ctx context.Context, dbConn *gorm.DB) error {
+	env, err := seed.RequiredEnv("SEED_ADMIN_EMAIL", "SEED_ADMIN_PASSWORD")
+	if err != nil {
+		return seed.Skip(err)
+	}
+	if id := os.Getenv("SEED_ADMIN_TENANT"); id != "" {
+		ctx = tenant.With(ctx, id)
+	}
+
+	adminRepo := db.NewGenericeRepo(dbConn, domain.UserAdmin{})
+	exist, err := adminRepo.IsExist(ctx, "email", env["SEED_ADMIN_EMAIL"])
+	if err != nil || exist {
+		return err
+	}
+
+	role, err := db.NewGenericeRepo(dbConn, domain.MasterRole{}).
+		FindOneByExpression(ctx, db.Query(db.Equal(constant.RoleIsAdmin, "master_roles.name")))
+	if err != nil {
+		return err
+	}
+
+	password, err := davinci.DefaultDavinci().HashAndSalt([]byte(env["SEED_ADMIN_PASSWORD"]))
+	if err != nil {
+		return err
+	}
+
+	name := os.Getenv("SEED_ADMIN_NAME")
+	if name == "" {
+		name = "Administrator"
+	}
+
+	admin := domain.UserAdmin{
+		FullName: name,
+		Email:    env["SEED_ADMIN_EMAIL"],
+		RoleID:   role.ID,
+		Password: password,
+	}
+	admin.SetIsVerified(true)
+
+	_, err = adminRepo.Store(ctx, admin)
+	return err
+}
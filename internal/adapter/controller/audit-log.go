@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"base-be-golang/internal/constant"
+	"base-be-golang/internal/core/usecase/auditlog"
+	"base-be-golang/pkg/db"
+	"base-be-golang/shared/base"
+	"base-be-golang/shared/payload"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AuditLogController struct {
+	base.BaseController
+	uc AuditLogUsecase
+}
+
+type AuditLogUsecase interface {
+	GetList(ctx context.Context, query auditlog.GetListAuditLogQuery) (payload.PaginationResponse[db.AuditLog], error)
+}
+
+func NewAuditLogController(dbConn *gorm.DB, port base.Port, controller base.BaseController) AuditLogController {
+	return AuditLogController{
+		BaseController: controller,
+		uc:             auditlog.NewUsecase(dbConn, port),
+	}
+}
+
+func (ctrl AuditLogController) GetListAuditLog(c *gin.Context) {
+	var request = auditlog.GetListAuditLogQuery{
+		Filter: &payload.GetListQueryNoPeriod{},
+	}
+	if errs := ctrl.Enigma.BindQueryToFilterAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	request.Filter.SetIfEmpty()
+	result, err := ctrl.uc.GetList(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant.GetListAuditLog), err)
+}
+
+// GetEntityHistory history of single entity, e.g. /audit-logs/user_admins/12
+func (ctrl AuditLogController) GetEntityHistory(c *gin.Context) {
+	var request = auditlog.GetListAuditLogQuery{
+		Filter: &payload.GetListQueryNoPeriod{},
+	}
+	if errs := ctrl.Enigma.BindQueryToFilterAndValidate(c, &request); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, payload.DefaultInvalidInputFormResponse(errs))
+		return
+	}
+
+	request.Entity = c.Param("entity")
+	request.EntityID = c.Param("entityId")
+	request.Filter.SetIfEmpty()
+	result, err := ctrl.uc.GetList(c.Request.Context(), request)
+	ctrl.Mapper.NewResponse(c, payload.NewSuccessResponse(result, constant.GetListAuditLog), err)
+}
+
+func (ctrl AuditLogController) Route(router *gin.RouterGroup) {
+	auditLog := router.Group("/audit-logs",
+		ctrl.Security.Validate(),
+		ctrl.Security.Authorize(constant.RoleIsAdmin),
+	)
+
+	auditLog.GET("", ctrl.GetListAuditLog)
+	auditLog.GET("/:entity/:entityId", ctrl.GetEntityHistory)
+}
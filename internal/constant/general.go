@@ -28,4 +28,10 @@ const (
 	MIMEJPEG = "image/jpeg"
 	MIMEPDF  = "application/pdf"
 	MIMEXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	//	Role, same value as iam module
+	RoleIsAdmin = "ADMIN"
+
+	//	Response message
+	GetListAuditLog = "GetListAuditLog"
 )
@@ -0,0 +1,11 @@
+package auditlog
+
+import "base-be-golang/shared/payload"
+
+type GetListAuditLogQuery struct {
+	Filter   *payload.GetListQueryNoPeriod `bindQuery:"dive=true" json:"filter"`
+	Entity   string                        `json:"entity"`
+	EntityID string                        `json:"entityId"`
+	Action   string                        `json:"action"`
+	Actor    string                        `json:"actor"`
+}
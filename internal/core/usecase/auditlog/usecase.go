@@ -0,0 +1,46 @@
+package auditlog
+
+import (
+	"base-be-golang/pkg/db"
+	"base-be-golang/shared/base"
+	"base-be-golang/shared/payload"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Usecase struct {
+	base.Port
+	auditLogRepo db.GenericRepository[db.AuditLog]
+}
+
+func NewUsecase(dbConn *gorm.DB, port base.Port) Usecase {
+	return Usecase{
+		Port:         port,
+		auditLogRepo: db.NewGenericeRepo(dbConn, db.AuditLog{}),
+	}
+}
+
+func (u Usecase) GetList(ctx context.Context, query GetListAuditLogQuery) (payload.PaginationResponse[db.AuditLog], error) {
+	conditions := db.Query(
+		db.When(query.Entity != "", db.Equal(query.Entity, "entity")),
+		db.When(query.EntityID != "", db.Equal(query.EntityID, "entity_id")),
+		db.When(query.Action != "", db.Equal(query.Action, "action")),
+		db.When(query.Actor != "", db.Equal(query.Actor, "actor")),
+	)
+
+	result, total, err := u.auditLogRepo.FindPagedByExpression(
+		ctx,
+		conditions,
+		db.PaginationQuery{
+			PerPage: query.Filter.PerPage,
+			Page:    query.Filter.Page,
+		},
+		db.OrderDesc("id"),
+	)
+	if err != nil {
+		return payload.PaginationResponse[db.AuditLog]{}, u.ErrHandler.ErrorReturn(err)
+	}
+
+	return payload.NewPagination(result, total, query.Filter.PerPage, query.Filter.Page), nil
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018000000",
+		Name:    "create_audit_logs",
+		Up: func(tx *gorm.DB) error {
+			type auditLog struct {
+				ID        uint            `gorm:"primaryKey;autoIncrement"`
+				Entity    string          `gorm:"column:entity;type:varchar(100);index:idx_audit_logs_entity,priority:1"`
+				EntityID  string          `gorm:"column:entity_id;type:varchar(100);index:idx_audit_logs_entity,priority:2"`
+				Actor     string          `gorm:"column:actor;type:varchar(100)"`
+				Action    string          `gorm:"column:action;type:varchar(20)"`
+				OldValues json.RawMessage `gorm:"column:old_values;type:json"`
+				NewValues json.RawMessage `gorm:"column:new_values;type:json"`
+				CreatedAt time.Time       `gorm:"column:created_at"`
+			}
+			return tx.Table("audit_logs").Migrator().CreateTable(&auditLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("audit_logs")
+		},
+	})
+}
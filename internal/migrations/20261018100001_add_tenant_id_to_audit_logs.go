@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(migration.Migration{
+		Version: "20261018100001",
+		Name:    "add_tenant_id_to_audit_logs",
+		Up: func(tx *gorm.DB) error {
+			type auditLog struct {
+				TenantID string `gorm:"column:tenant_id;type:varchar(64);not null;default:'';index:idx_audit_logs_tenant_id"`
+			}
+			migrator := tx.Table("audit_logs").Migrator()
+			if err := migrator.AddColumn(&auditLog{}, "TenantID"); err != nil {
+				return err
+			}
+			return migrator.CreateIndex(&auditLog{}, "idx_audit_logs_tenant_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			type auditLog struct {
+				TenantID string `gorm:"column:tenant_id;type:varchar(64);index:idx_audit_logs_tenant_id"`
+			}
+			migrator := tx.Table("audit_logs").Migrator()
+			if err := migrator.DropIndex(&auditLog{}, "idx_audit_logs_tenant_id"); err != nil {
+				return err
+			}
+			return migrator.DropColumn(&auditLog{}, "TenantID")
+		},
+	})
+}
@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"base-be-golang/pkg/migration"
+)
+
+var migrations []migration.Migration
+
+// register is called by init of every migration file, create one with `go run ./cmd/migrate create <name>`
+func register(m migration.Migration) {
+	migrations = append(migrations, m)
+}
+
+// Source business module migrations
+func Source() migration.Source {
+	return migration.Source{
+		Module:     "app",
+		Migrations: migrations,
+	}
+}
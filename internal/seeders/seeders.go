@@ -0,0 +1,13 @@
+package seeders
+
+import (
+	"base-be-golang/pkg/seed"
+)
+
+// Source business module seeders, run after the iam seeders
+func Source() seed.Source {
+	return seed.Source{
+		Module:  "app",
+		Seeders: []seed.Seeder{},
+	}
+}
@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"base-be-golang/pkg/tenant"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -9,6 +11,7 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// DbClient keys are namespaced per tenant of ctx (see tenant.Key), use tenant.Without for key shared by every tenant
 type DbClient struct {
 	client *redis.Client
 }
@@ -33,7 +36,7 @@ func Default() DbClient {
 
 // Set stores value in a key with expiration.
 func (rdb *DbClient) Set(ctx context.Context, key string, value interface{}, exp time.Duration) error {
-	err := rdb.client.Set(ctx, key, value, exp).Err()
+	err := rdb.client.Set(ctx, tenant.Key(ctx, key), value, exp).Err()
 	if err != nil {
 		return err
 	}
@@ -43,7 +46,7 @@ func (rdb *DbClient) Set(ctx context.Context, key string, value interface{}, exp
 
 // SetNX stores value if not exists (Not eXists) in a key with expiration.
 func (rdb *DbClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	err := rdb.client.SetNX(ctx, key, value, expiration).Err()
+	err := rdb.client.SetNX(ctx, tenant.Key(ctx, key), value, expiration).Err()
 	if err != nil {
 		return err
 	}
@@ -51,9 +54,14 @@ func (rdb *DbClient) SetNX(ctx context.Context, key string, value interface{}, e
 	return nil
 }
 
+// SetXX stores value only if the key still exists, false when it does not.
+func (rdb *DbClient) SetXX(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error) {
+	return rdb.client.SetXX(ctx, tenant.Key(ctx, key), value, exp).Result()
+}
+
 // Get retrieves key in form of string.
 func (rdb *DbClient) Get(ctx context.Context, key string) (string, error) {
-	value, err := rdb.client.Get(ctx, key).Result()
+	value, err := rdb.client.Get(ctx, tenant.Key(ctx, key)).Result()
 	if err != nil {
 		return "", err
 	}
@@ -61,7 +69,50 @@ func (rdb *DbClient) Get(ctx context.Context, key string) (string, error) {
 	return value, nil
 }
 
+// GetDel retrieves key and deletes it in one step, only one of concurrent callers get the value.
+func (rdb *DbClient) GetDel(ctx context.Context, key string) (string, error) {
+	return rdb.client.GetDel(ctx, tenant.Key(ctx, key)).Result()
+}
+
 // Delete deletes keys.
 func (rdb *DbClient) Delete(ctx context.Context, keys ...string) error {
-	return rdb.client.Del(ctx, keys...).Err()
+	var scoped = make([]string, len(keys))
+	for i, key := range keys {
+		scoped[i] = tenant.Key(ctx, key)
+	}
+	return rdb.client.Del(ctx, scoped...).Err()
+}
+
+// SAdd adds members to the set in key, expiration is applied to the whole set.
+func (rdb *DbClient) SAdd(ctx context.Context, key string, exp time.Duration, members ...string) error {
+	key = tenant.Key(ctx, key)
+	_, err := rdb.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		values := make([]interface{}, len(members))
+		for i, member := range members {
+			values[i] = member
+		}
+		pipe.SAdd(ctx, key, values...)
+		pipe.Expire(ctx, key, exp)
+		return nil
+	})
+	return err
+}
+
+// SMembers retrieves every member of the set in key, empty when key does not exist.
+func (rdb *DbClient) SMembers(ctx context.Context, key string) ([]string, error) {
+	return rdb.client.SMembers(ctx, tenant.Key(ctx, key)).Result()
+}
+
+// SRem removes members from the set in key.
+func (rdb *DbClient) SRem(ctx context.Context, key string, members ...string) error {
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+	return rdb.client.SRem(ctx, tenant.Key(ctx, key), values...).Err()
+}
+
+// IsMiss true when Get error because the key does not exist
+func IsMiss(err error) bool {
+	return errors.Is(err, redis.Nil)
 }
@@ -11,8 +11,10 @@ import (
 	"crypto/sha256"
 	"encoding/base32"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
+	"os"
 	"strconv"
 	"time"
 
@@ -21,7 +23,13 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// ErrPasswordTooLong bcrypt only hash the first 72 bytes, longer password is rejected instead of truncated
+var ErrPasswordTooLong = bcrypt.ErrPasswordTooLong
+
+const defaultPasswordCost = 12
+
 type Engine struct {
+	passwordCost int
 }
 
 func (dc Engine) GenerateHashValue(
@@ -163,8 +171,13 @@ func (dc Engine) GenerateHash(secretKey []byte, uniqueID string) (string, error)
 	return hashStr, nil
 }
 
+// DefaultDavinci bcrypt cost of password hash from env PASSWORD_HASH_COST (default 12)
 func DefaultDavinci() Engine {
-	return Engine{}
+	cost, err := strconv.Atoi(os.Getenv("PASSWORD_HASH_COST"))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = defaultPasswordCost
+	}
+	return Engine{passwordCost: cost}
 }
 
 func (dc Engine) GenerateOTPCode(
@@ -209,22 +222,45 @@ func (dc Engine) GenerateOTPCode(
 }
 
 func (dc Engine) HashAndSalt(pwd []byte) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword(pwd, bcrypt.DefaultCost)
+	hash, err := bcrypt.GenerateFromPassword(pwd, dc.cost())
 	if err != nil {
 		return "", err
 	}
 	return string(hash), nil
 }
 
+// ComparePassword false without error when the password does not match
 func (dc Engine) ComparePassword(hashedPwd string, pwd []byte) (bool, error) {
 	HashedByte := []byte(hashedPwd)
 	err := bcrypt.CompareHashAndPassword(HashedByte, pwd)
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
+// IsPasswordHash true for hash of HashAndSalt, false for password stored with EncryptMessage
+func (dc Engine) IsPasswordHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// NeedsRehash true when the hash was made with another cost than the current one
+func (dc Engine) NeedsRehash(hashedPwd string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPwd))
+	return err != nil || cost != dc.cost()
+}
+
+func (dc Engine) cost() int {
+	if dc.passwordCost == 0 {
+		return defaultPasswordCost
+	}
+	return dc.passwordCost
+}
+
 func (d Engine) DeriveKey(password, salt []byte) ([]byte, []byte, error) {
 	if salt == nil {
 		salt = make([]byte, 32)
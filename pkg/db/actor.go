@@ -0,0 +1,79 @@
+package db
+
+import (
+	"base-be-golang/shared/payload"
+	"context"
+	"os"
+)
+
+const SystemActor = "system"
+
+type actorCtxKey struct{}
+
+type skipAuditCtxKey struct{}
+
+// Auditable entity get created_by/updated_by filled by GenericRepository on write
+type Auditable interface {
+	SetCreated(actor string)
+	SetUpdated(actor string)
+}
+
+// WithActor override actor for write inside ctx, e.g. self registration or background job
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// WithoutAudit mark write inside ctx as non business change (e.g. last active), audit columns stay untouched
+func WithoutAudit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipAuditCtxKey{}, true)
+}
+
+func isAuditSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipAuditCtxKey{}).(bool)
+	return skip
+}
+
+/*
+ActorFromContext resolve actor in order:
+  - actor set by WithActor
+  - user attached by auth middleware (email, then user id)
+  - DB_SYSTEM_ACTOR env, default SystemActor
+*/
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorCtxKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	if user, ok := ctx.Value(payload.AuthCodeContext).(payload.UserData); ok {
+		if user.Email != "" {
+			return user.Email
+		}
+		if user.UserId != "" {
+			return user.UserId
+		}
+	}
+
+	if actor := os.Getenv("DB_SYSTEM_ACTOR"); actor != "" {
+		return actor
+	}
+
+	return SystemActor
+}
+
+func stampCreated(ctx context.Context, data interface{}) {
+	if isAuditSkipped(ctx) {
+		return
+	}
+	if entity, ok := data.(Auditable); ok {
+		entity.SetCreated(ActorFromContext(ctx))
+	}
+}
+
+func stampUpdated(ctx context.Context, data interface{}) {
+	if isAuditSkipped(ctx) {
+		return
+	}
+	if entity, ok := data.(Auditable); ok {
+		entity.SetUpdated(ActorFromContext(ctx))
+	}
+}
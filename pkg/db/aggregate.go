@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Period of GroupPeriod, same value as PeriodType constant of the app
+const (
+	PeriodDay   = "DAY"
+	PeriodMonth = "MONTH"
+	PeriodYear  = "YEAR"
+)
+
+/*
+Aggregate one aggregated column scanned into Alias of the projection struct (gorm naming, e.g. total -> Total).
+SUM is 0 on empty set, AVG, MIN and MAX are NULL so use pointer or sql.Null* field when the set can be empty.
+*/
+type Aggregate struct {
+	fn       string
+	column   string
+	alias    string
+	distinct bool
+}
+
+func Sum(col string, alias string) Aggregate {
+	return Aggregate{fn: "SUM", column: col, alias: alias}
+}
+
+func Avg(col string, alias string) Aggregate {
+	return Aggregate{fn: "AVG", column: col, alias: alias}
+}
+
+func Min(col string, alias string) Aggregate {
+	return Aggregate{fn: "MIN", column: col, alias: alias}
+}
+
+func Max(col string, alias string) Aggregate {
+	return Aggregate{fn: "MAX", column: col, alias: alias}
+}
+
+// Count number of rows in group
+func Count(alias string) Aggregate {
+	return Aggregate{fn: "COUNT", alias: alias}
+}
+
+func CountDistinct(col string, alias string) Aggregate {
+	return Aggregate{fn: "COUNT", column: col, alias: alias, distinct: true}
+}
+
+func (a Aggregate) expr(op string, val interface{}) clause.Expression {
+	var sql string
+	switch {
+	case a.column == "":
+		return clause.Expr{SQL: "COUNT(*) " + op + " ?", Vars: []interface{}{val}}
+	case a.distinct:
+		sql = "COUNT(DISTINCT ?)"
+	case a.fn == "SUM":
+		sql = "COALESCE(SUM(?), 0)"
+	default:
+		sql = a.fn + "(?)"
+	}
+	return clause.Expr{SQL: sql + " " + op + " ?", Vars: []interface{}{Column(a.column), val}}
+}
+
+func (a Aggregate) sql(db *gorm.DB) string {
+	switch {
+	case a.column == "":
+		return "COUNT(*)"
+	case a.distinct:
+		return fmt.Sprintf("COUNT(DISTINCT %s)", db.Statement.Quote(Column(a.column)))
+	case a.fn == "SUM":
+		return fmt.Sprintf("COALESCE(SUM(%s), 0)", db.Statement.Quote(Column(a.column)))
+	default:
+		return fmt.Sprintf("%s(%s)", a.fn, db.Statement.Quote(Column(a.column)))
+	}
+}
+
+// Gt having aggregate > val, e.g. db.Sum("amount", "total").Gt(0)
+func (a Aggregate) Gt(val interface{}) clause.Expression {
+	return a.expr(">", val)
+}
+
+func (a Aggregate) Gte(val interface{}) clause.Expression {
+	return a.expr(">=", val)
+}
+
+func (a Aggregate) Lt(val interface{}) clause.Expression {
+	return a.expr("<", val)
+}
+
+func (a Aggregate) Lte(val interface{}) clause.Expression {
+	return a.expr("<=", val)
+}
+
+func (a Aggregate) Eq(val interface{}) clause.Expression {
+	return a.expr("=", val)
+}
+
+// GroupColumn group by column, selected as alias so it can be scanned next to the aggregates
+type GroupColumn struct {
+	column string
+	period string
+	alias  string
+}
+
+// Group by "table.col" or "col", selected as the column name
+func Group(col string) GroupColumn {
+	_, name := getColNameStr(col)
+	return GroupColumn{column: col, alias: name}
+}
+
+func GroupAs(col string, alias string) GroupColumn {
+	return GroupColumn{column: col, alias: alias}
+}
+
+/*
+GroupPeriod group date/time col by PeriodDay (2006-01-02), PeriodMonth (2006-01) or PeriodYear (2006),
+the period is selected as string and follow the timezone stored in DB (UTC).
+*/
+func GroupPeriod(col string, period string, alias string) GroupColumn {
+	return GroupColumn{column: col, period: period, alias: alias}
+}
+
+// periodFormats layout per dialect: mysql DATE_FORMAT, postgres to_char, sqlite strftime
+var periodFormats = map[string]map[string]string{
+	PeriodDay:   {DriverMySQL: "%Y-%m-%d", DriverPostgres: "YYYY-MM-DD", DriverSQLite: "%Y-%m-%d"},
+	PeriodMonth: {DriverMySQL: "%Y-%m", DriverPostgres: "YYYY-MM", DriverSQLite: "%Y-%m"},
+	PeriodYear:  {DriverMySQL: "%Y", DriverPostgres: "YYYY", DriverSQLite: "%Y"},
+}
+
+func (g GroupColumn) sql(db *gorm.DB) (string, error) {
+	column := db.Statement.Quote(Column(g.column))
+	if g.period == "" {
+		return column, nil
+	}
+
+	dialect := db.Dialector.Name()
+	layout, ok := periodFormats[g.period][dialect]
+	if !ok {
+		return "", fmt.Errorf("unsupported period %q on %s", g.period, dialect)
+	}
+
+	switch dialect {
+	case DriverPostgres:
+		return fmt.Sprintf("to_char(%s, '%s')", column, layout), nil
+	case DriverSQLite:
+		return fmt.Sprintf("strftime('%s', %s)", layout, column), nil
+	default:
+		return fmt.Sprintf("DATE_FORMAT(%s, '%s')", column, layout), nil
+	}
+}
+
+/*
+AggregateQuery grouped aggregation.
+  - Select: aggregates, at least one
+  - Group: group columns, also selected
+  - Having: condition on aggregate, see Aggregate.Gt
+  - Order: by group or aggregate alias
+*/
+type AggregateQuery struct {
+	Select []Aggregate
+	Group  []GroupColumn
+	Having []clause.Expression
+	Join   []string
+	Order  []OrderBy
+}
+
+/*
+AggregateByExpression scan grouped aggregates into dest, pointer to projection struct or slice of it.
+
+	type omsetPerMonth struct {
+		Month string
+		Total float64
+		Payer int
+	}
+
+	var result []omsetPerMonth
+	err := repo.AggregateByExpression(ctx, &result,
+		db.Query(db.Equal("PAID", "payments.status")),
+		db.AggregateQuery{
+			Select: []db.Aggregate{db.Sum("payments.amount", "total"), db.CountDistinct("payments.user_id", "payer")},
+			Group:  []db.GroupColumn{db.GroupPeriod("payments.paid_at", db.PeriodMonth, "month")},
+			Order:  db.Order(db.OrderAsc("month")),
+		},
+	)
+*/
+func (repo GenericRepository[T]) AggregateByExpression(ctx context.Context, dest interface{}, cond []clause.Expression, query AggregateQuery) error {
+	if len(query.Select) == 0 {
+		return fmt.Errorf("aggregate of %s needs at least one aggregate", repo.model.TableName())
+	}
+
+	db := repo.conn(ctx).Model(&repo.model)
+	for _, j := range query.Join {
+		db = db.Joins(j)
+	}
+
+	var selects = make([]string, 0, len(query.Group)+len(query.Select))
+	for _, g := range query.Group {
+		col, err := g.sql(db)
+		if err != nil {
+			return err
+		}
+		selects = append(selects, col+" AS "+db.Statement.Quote(g.alias))
+		db = db.Group(col)
+	}
+	for _, a := range query.Select {
+		selects = append(selects, a.sql(db)+" AS "+db.Statement.Quote(a.alias))
+	}
+
+	db = db.Select(strings.Join(selects, ", ")).
+		Clauses(clause.Where{Exprs: cond})
+	for _, h := range query.Having {
+		db = db.Having(h)
+	}
+
+	return applyOrder(db, query.Order).Scan(dest).Error
+}
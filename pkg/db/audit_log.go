@@ -0,0 +1,396 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+
+	auditMaskValue = "******"
+)
+
+/*
+AuditTrailed entity write change history to audit_logs on every create, update and delete.
+Field tag `audit:"-"` exclude the field, `audit:"mask"` record the change but hide the value.
+
+	type UserAdmin struct {
+		Password   string       `audit:"mask" json:"password"`
+		LastActive sql.NullTime `audit:"-" json:"lastActive"`
+	}
+
+	func (receiver UserAdmin) AuditEntity() string { return receiver.TableName() }
+*/
+type AuditTrailed interface {
+	AuditEntity() string
+}
+
+// AuditLog one row per changed entity, OldValues/NewValues only contain changed columns on update
+type AuditLog struct {
+	ID uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenantScope
+	Entity    string          `gorm:"column:entity;type:varchar(100);index:idx_audit_logs_entity,priority:1" json:"entity"`
+	EntityID  string          `gorm:"column:entity_id;type:varchar(100);index:idx_audit_logs_entity,priority:2" json:"entityId"`
+	Actor     string          `gorm:"column:actor;type:varchar(100)" json:"actor"`
+	Action    string          `gorm:"column:action;type:varchar(20)" json:"action"`
+	OldValues json.RawMessage `gorm:"column:old_values;type:json" json:"before"`
+	NewValues json.RawMessage `gorm:"column:new_values;type:json" json:"after"`
+	CreatedAt time.Time       `gorm:"column:created_at;autoCreateTime:false" json:"createdAt"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+type auditSnapshot map[string]interface{}
+
+func auditFieldOption(tag reflect.StructTag) (skip bool, mask bool) {
+	switch tag.Get("audit") {
+	case "-":
+		return true, false
+	case "mask":
+		return false, true
+	}
+	return false, false
+}
+
+func auditValue(val interface{}) interface{} {
+	if b, ok := val.([]byte); ok {
+		return string(b)
+	}
+	if valuer, ok := val.(driver.Valuer); ok {
+		if v, err := valuer.Value(); err == nil {
+			return v
+		}
+	}
+	return val
+}
+
+func isAuditValueEqual(a interface{}, b interface{}) bool {
+	aJson, errA := json.Marshal(a)
+	bJson, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aJson) == string(bJson)
+}
+
+/*
+auditDiff return changed columns only, masked column keep its key without value.
+Create has nil before, delete has nil after.
+*/
+func auditDiff(before auditSnapshot, after auditSnapshot, masked map[string]bool) (auditSnapshot, auditSnapshot) {
+	var oldValues, newValues auditSnapshot
+	if before != nil {
+		oldValues = auditSnapshot{}
+	}
+	if after != nil {
+		newValues = auditSnapshot{}
+	}
+
+	for col, val := range before {
+		if after != nil && isAuditValueEqual(val, after[col]) {
+			continue
+		}
+		oldValues[col] = val
+	}
+	for col, val := range after {
+		if before != nil && isAuditValueEqual(val, before[col]) {
+			continue
+		}
+		newValues[col] = val
+	}
+
+	for col := range masked {
+		if _, ok := oldValues[col]; ok {
+			oldValues[col] = auditMaskValue
+		}
+		if _, ok := newValues[col]; ok {
+			newValues[col] = auditMaskValue
+		}
+	}
+
+	return oldValues, newValues
+}
+
+func writeAuditLog(
+	ctx context.Context,
+	tx *gorm.DB,
+	entity string,
+	entityID string,
+	action string,
+	before auditSnapshot,
+	after auditSnapshot,
+	masked map[string]bool,
+) error {
+	oldValues, newValues := auditDiff(before, after, masked)
+	if action == AuditActionUpdate && len(oldValues) == 0 && len(newValues) == 0 {
+		return nil
+	}
+
+	oldJson, err := json.Marshal(oldValues)
+	if err != nil {
+		return err
+	}
+	newJson, err := json.Marshal(newValues)
+	if err != nil {
+		return err
+	}
+
+	return tx.Session(&gorm.Session{NewDB: true}).
+		Create(&AuditLog{
+			Entity:    entity,
+			EntityID:  entityID,
+			Actor:     ActorFromContext(ctx),
+			Action:    action,
+			OldValues: oldJson,
+			NewValues: newJson,
+			CreatedAt: time.Now().UTC(),
+		}).Error
+}
+
+/*
+================ GENERIC REPOSITORY ==============
+*/
+
+type auditTrail struct {
+	entity string
+	schema *schema.Schema
+	fields []*schema.Field
+	masked map[string]bool
+}
+
+func (repo GenericRepository[T]) auditTrail() (*auditTrail, error) {
+	trailed, ok := any(&repo.model).(AuditTrailed)
+	if !ok {
+		return nil, nil
+	}
+
+	sch, err := repo.parseSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	trail := auditTrail{
+		entity: trailed.AuditEntity(),
+		schema: sch,
+		masked: map[string]bool{},
+	}
+	for _, f := range sch.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		skip, mask := auditFieldOption(f.Tag)
+		if skip {
+			continue
+		}
+		if mask {
+			trail.masked[f.DBName] = true
+		}
+		trail.fields = append(trail.fields, f)
+	}
+
+	return &trail, nil
+}
+
+func (trail auditTrail) snapshot(ctx context.Context, row reflect.Value) auditSnapshot {
+	var result = auditSnapshot{}
+	for _, f := range trail.fields {
+		val, _ := f.ValueOf(ctx, row)
+		result[f.DBName] = auditValue(val)
+	}
+	return result
+}
+
+func (trail auditTrail) primaryKey(ctx context.Context, row reflect.Value) string {
+	val, _ := trail.schema.PrioritizedPrimaryField.ValueOf(ctx, row)
+	return fmt.Sprint(auditValue(val))
+}
+
+func primaryKeyIn(ctx context.Context, sch *schema.Schema, rows reflect.Value) func(tx *gorm.DB) *gorm.DB {
+	var ids = make([]interface{}, 0, rows.Len())
+	for i := 0; i < rows.Len(); i++ {
+		val, _ := sch.PrioritizedPrimaryField.ValueOf(ctx, reflect.Indirect(rows.Index(i)))
+		ids = append(ids, val)
+	}
+
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(clause.IN{
+			Column: clause.Column{Table: sch.Table, Name: sch.PrioritizedPrimaryField.DBName},
+			Values: ids,
+		})
+	}
+}
+
+// byPrimaryKey scope affected rows of write which receive entity
+func (trail auditTrail) byPrimaryKey(ctx context.Context, rows reflect.Value) func(tx *gorm.DB) *gorm.DB {
+	return primaryKeyIn(ctx, trail.schema, rows)
+}
+
+func (trail auditTrail) write(ctx context.Context, tx *gorm.DB, action string, before reflect.Value, after reflect.Value) error {
+	var afterByKey = map[string]reflect.Value{}
+	for i := 0; i < after.Len(); i++ {
+		row := reflect.Indirect(after.Index(i))
+		afterByKey[trail.primaryKey(ctx, row)] = row
+	}
+
+	for i := 0; i < before.Len(); i++ {
+		row := reflect.Indirect(before.Index(i))
+		key := trail.primaryKey(ctx, row)
+
+		var afterSnapshot auditSnapshot
+		if afterRow, ok := afterByKey[key]; ok {
+			afterSnapshot = trail.snapshot(ctx, afterRow)
+			delete(afterByKey, key)
+		}
+		err := writeAuditLog(ctx, tx, trail.entity, key, action, trail.snapshot(ctx, row), afterSnapshot, trail.masked)
+		if err != nil {
+			return err
+		}
+	}
+
+	// created rows, nothing before
+	for i := 0; i < after.Len(); i++ {
+		row := reflect.Indirect(after.Index(i))
+		key := trail.primaryKey(ctx, row)
+		if _, ok := afterByKey[key]; !ok {
+			continue
+		}
+		err := writeAuditLog(ctx, tx, trail.entity, key, action, nil, trail.snapshot(ctx, row), trail.masked)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// byPrimaryKey scope for write which receive the entity itself
+func (repo GenericRepository[T]) byPrimaryKey(ctx context.Context, rows []T) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		sch, err := repo.parseSchema()
+		if err != nil {
+			_ = tx.AddError(err)
+			return tx
+		}
+		return primaryKeyIn(ctx, sch, reflect.ValueOf(rows))(tx)
+	}
+}
+
+/*
+withAuditLog run write together with its audit log in one transaction.
+  - scope: select rows affected by write, nil on create
+  - created: rows inserted by write, nil on update and delete
+*/
+func (repo GenericRepository[T]) withAuditLog(
+	ctx context.Context,
+	action string,
+	scope func(tx *gorm.DB) *gorm.DB,
+	created func() []T,
+	write func(tx *gorm.DB) error,
+) error {
+	trail, err := repo.auditTrail()
+	if err != nil {
+		return err
+	}
+	if trail == nil || isAuditSkipped(ctx) {
+		return write(repo.conn(ctx))
+	}
+
+	return repo.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var before []T
+		if scope != nil {
+			err := scope(tx.Session(&gorm.Session{NewDB: true})).
+				Find(&before).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := write(tx); err != nil {
+			return err
+		}
+
+		var after []T
+		switch {
+		case created != nil:
+			after = created()
+		case action != AuditActionDelete && len(before) > 0:
+			err := trail.byPrimaryKey(ctx, reflect.ValueOf(before))(tx.Session(&gorm.Session{NewDB: true})).
+				Unscoped().
+				Find(&after).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return trail.write(ctx, tx, action, reflect.ValueOf(before), reflect.ValueOf(after))
+	})
+}
+
+/*
+================ CUSTOM ORM ==============
+*/
+
+func (repo *CustomORM) auditLog(model interface{}, action string, id interface{}, cols []string, before auditSnapshot) error {
+	trailed, ok := model.(AuditTrailed)
+	ctx := repo.db.Statement.Context
+	if !ok || isAuditSkipped(ctx) || repo.db.Error != nil {
+		return nil
+	}
+
+	sType := reflect.TypeOf(model)
+	sVal := reflect.ValueOf(model)
+	if sType.Kind() == reflect.Pointer {
+		sType = sType.Elem()
+		sVal = sVal.Elem()
+	}
+
+	var after = auditSnapshot{}
+	var masked = map[string]bool{}
+	for i := 0; i < sType.NumField(); i++ {
+		f := sType.Field(i)
+		colName := getColName(f.Tag.Get("gorm"), f)
+		skip, mask := auditFieldOption(f.Tag)
+		if skip || (len(cols) > 0 && !slices.Contains(cols, colName)) {
+			continue
+		}
+		if mask {
+			masked[colName] = true
+		}
+		after[colName] = auditValue(sVal.Field(i).Interface())
+	}
+
+	return writeAuditLog(ctx, repo.db, trailed.AuditEntity(), fmt.Sprint(id), action, before, after, masked)
+}
+
+func (repo *CustomORM) auditBefore(model interface{}, tableName string, id interface{}, cols []string) auditSnapshot {
+	if _, ok := model.(AuditTrailed); !ok || isAuditSkipped(repo.db.Statement.Context) {
+		return nil
+	}
+
+	// gorm scan only the plain map type
+	var before = map[string]interface{}{}
+	err := repo.db.Session(&gorm.Session{NewDB: true}).
+		Table(tableName).
+		Select(cols).
+		Where("id = "+uuidPlaceholder(repo.db), fmt.Sprint(id)).
+		Take(&before).Error
+	if err != nil {
+		return nil
+	}
+	return before
+}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"base-be-golang/pkg/cache"
+	"base-be-golang/pkg/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const defaultCacheTTL = 5 * time.Minute
+
+// CacheStore key value store of CachedRepository, satisfied by *cache.DbClient and base.Cache
+type CacheStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+/*
+CachedRepository read-through cache on top of GenericRepository for FindOneByID and FindAll,
+every other method goes to the database. Entity is stored as JSON, so field with json:"-" is not cached.
+
+Writes through the repository invalidate the cache once the transaction is committed (see AfterCommit):
+write with known primary key drop the entity and FindAll key, expression write (DeleteByExpression,
+RestoreByExpression, PurgeOlderThan, BulkUpsert) move the table to a new cache generation.
+Write made outside the repository (CustomORM, raw SQL, other service) is only seen after ttl.
+
+Concurrent miss on the same key in one instance share one query (stampede protection), ttl has up to
+10% jitter so hot keys do not expire together. Read inside a transaction or ReadYourWrites skip the cache,
+and the database is used when the cache is not reachable.
+
+	roleRepo := db.NewCachedRepo(dbConn, domain.MasterRole{}, port.Cache, time.Hour)
+*/
+type CachedRepository[T schema.Tabler] struct {
+	GenericRepository[T]
+	cache  CacheStore
+	ttl    time.Duration
+	flight *singleflight.Group
+}
+
+// NewCachedRepo ttl is per entity, zero means 5 minutes
+func NewCachedRepo[T schema.Tabler](db *gorm.DB, model T, store CacheStore, ttl time.Duration) CachedRepository[T] {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return CachedRepository[T]{
+		GenericRepository: NewGenericeRepo(db, model),
+		cache:             store,
+		ttl:               ttl,
+		flight:            &singleflight.Group{},
+	}
+}
+
+func (repo CachedRepository[T]) FindOneByID(ctx context.Context, id interface{}) (T, error) {
+	return cached(ctx, repo, "id:"+fmt.Sprint(id), func(ctx context.Context) (T, error) {
+		return repo.GenericRepository.FindOneByID(ctx, id)
+	})
+}
+
+func (repo CachedRepository[T]) FindAll(ctx context.Context) ([]T, error) {
+	return cached(ctx, repo, "all", func(ctx context.Context) ([]T, error) {
+		return repo.GenericRepository.FindAll(ctx)
+	})
+}
+
+/*
+================ WRITE ==============
+*/
+
+func (repo CachedRepository[T]) Store(ctx context.Context, data T) (T, error) {
+	data, err := repo.GenericRepository.Store(ctx, data)
+	return data, repo.invalidate(ctx, err)
+}
+
+func (repo CachedRepository[T]) StoreExclude(ctx context.Context, data T, ignore ...string) (T, error) {
+	data, err := repo.GenericRepository.StoreExclude(ctx, data, ignore...)
+	return data, repo.invalidate(ctx, err)
+}
+
+func (repo CachedRepository[T]) BulkStore(ctx context.Context, data []T) ([]T, error) {
+	data, err := repo.GenericRepository.BulkStore(ctx, data)
+	return data, repo.invalidate(ctx, err)
+}
+
+func (repo CachedRepository[T]) BulkUpsert(ctx context.Context, data []T, query UpsertQuery) (UpsertResult, error) {
+	result, err := repo.GenericRepository.BulkUpsert(ctx, data, query)
+	if result.Inserted+result.Updated > 0 {
+		repo.invalidateAll(ctx)
+	}
+	return result, err
+}
+
+func (repo CachedRepository[T]) Update(ctx context.Context, data T) error {
+	err := repo.GenericRepository.Update(ctx, data)
+	return repo.invalidate(ctx, err, repo.primaryKeys(ctx, []T{data})...)
+}
+
+func (repo CachedRepository[T]) UpdateSelectedCols(ctx context.Context, data T, columns ...string) error {
+	err := repo.GenericRepository.UpdateSelectedCols(ctx, data, columns...)
+	return repo.invalidate(ctx, err, repo.primaryKeys(ctx, []T{data})...)
+}
+
+func (repo CachedRepository[T]) BulkUpdateSelectedColumn(ctx context.Context, children []T, fields ...string) error {
+	err := repo.GenericRepository.BulkUpdateSelectedColumn(ctx, children, fields...)
+	return repo.invalidate(ctx, err, repo.primaryKeys(ctx, children)...)
+}
+
+func (repo CachedRepository[T]) Delete(ctx context.Context, data T) error {
+	err := repo.GenericRepository.Delete(ctx, data)
+	return repo.invalidate(ctx, err, repo.primaryKeys(ctx, []T{data})...)
+}
+
+func (repo CachedRepository[T]) DeleteByID(ctx context.Context, id uint) error {
+	err := repo.GenericRepository.DeleteByID(ctx, id)
+	return repo.invalidate(ctx, err, strconv.FormatUint(uint64(id), 10))
+}
+
+func (repo CachedRepository[T]) BulkDelete(ctx context.Context, data []T) error {
+	err := repo.GenericRepository.BulkDelete(ctx, data)
+	return repo.invalidate(ctx, err, repo.primaryKeys(ctx, data)...)
+}
+
+func (repo CachedRepository[T]) Restore(ctx context.Context, id interface{}) error {
+	err := repo.GenericRepository.Restore(ctx, id)
+	return repo.invalidate(ctx, err, fmt.Sprint(id))
+}
+
+func (repo CachedRepository[T]) DeleteByExpression(ctx context.Context, exp []clause.Expression) error {
+	err := repo.GenericRepository.DeleteByExpression(ctx, exp)
+	if err == nil {
+		repo.invalidateAll(ctx)
+	}
+	return err
+}
+
+func (repo CachedRepository[T]) RestoreByExpression(ctx context.Context, exp []clause.Expression) error {
+	err := repo.GenericRepository.RestoreByExpression(ctx, exp)
+	if err == nil {
+		repo.invalidateAll(ctx)
+	}
+	return err
+}
+
+func (repo CachedRepository[T]) PurgeOlderThan(ctx context.Context, before time.Time) (int, error) {
+	total, err := repo.GenericRepository.PurgeOlderThan(ctx, before)
+	if err == nil && total > 0 {
+		repo.invalidateAll(ctx)
+	}
+	return total, err
+}
+
+/*
+================ CACHE ==============
+*/
+
+/*
+cached read key of the current generation, load and store it on miss.
+Shared load runs without the cancellation of the caller which started it, every caller gets its own copy
+decoded from the stored JSON, so a slice is not shared between requests.
+*/
+func cached[T schema.Tabler, R any](ctx context.Context, repo CachedRepository[T], name string, load func(ctx context.Context) (R, error)) (R, error) {
+	if _, ok := TxFromContext(ctx); ok || isReadYourWrites(ctx) {
+		return load(ctx)
+	}
+
+	generation, err := repo.generation(ctx)
+	if err != nil {
+		return load(ctx)
+	}
+	key := repo.key(generation, name)
+
+	if raw, err := repo.cache.Get(ctx, key); err == nil {
+		var result R
+		if json.Unmarshal([]byte(raw), &result) == nil {
+			return result, nil
+		}
+	}
+
+	// store namespace key per tenant (see cache.DbClient), the in-process flight must too
+	value, err, _ := repo.flight.Do(tenant.Key(ctx, key), func() (interface{}, error) {
+		ctx := context.WithoutCancel(ctx)
+		result, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		_ = repo.cache.Set(ctx, key, raw, repo.jitterTTL())
+		return raw, nil
+	})
+
+	var result R
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(value.([]byte), &result)
+	return result, err
+}
+
+func (repo CachedRepository[T]) generationKey() string {
+	return fmt.Sprintf("db:%s:generation", repo.model.TableName())
+}
+
+func (repo CachedRepository[T]) key(generation string, name string) string {
+	return fmt.Sprintf("db:%s:%s:%s", repo.model.TableName(), generation, name)
+}
+
+// generation "0" until the first expression write, error means the cache is not reachable
+func (repo CachedRepository[T]) generation(ctx context.Context) (string, error) {
+	generation, err := repo.cache.Get(ctx, repo.generationKey())
+	if cache.IsMiss(err) {
+		return "0", nil
+	}
+	return generation, err
+}
+
+func (repo CachedRepository[T]) jitterTTL() time.Duration {
+	return repo.ttl + time.Duration(rand.Int63n(int64(repo.ttl)/10+1))
+}
+
+func (repo CachedRepository[T]) primaryKeys(ctx context.Context, rows []T) []string {
+	sch, err := repo.parseSchema()
+	if err != nil {
+		return nil
+	}
+
+	var keys = make([]string, 0, len(rows))
+	for i := range rows {
+		val, zero := sch.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(&rows[i]).Elem())
+		if !zero {
+			keys = append(keys, fmt.Sprint(auditValue(val)))
+		}
+	}
+	return keys
+}
+
+// invalidate drop FindAll and the given entity keys after commit, only when the write succeed
+func (repo CachedRepository[T]) invalidate(ctx context.Context, err error, ids ...string) error {
+	if err != nil {
+		return err
+	}
+
+	AfterCommit(ctx, func() {
+		ctx := context.WithoutCancel(ctx)
+		generation, err := repo.generation(ctx)
+		if err != nil {
+			return
+		}
+
+		var keys = []string{repo.key(generation, "all")}
+		for _, id := range ids {
+			keys = append(keys, repo.key(generation, "id:"+id))
+		}
+		_ = repo.cache.Delete(ctx, keys...)
+	})
+	return nil
+}
+
+// invalidateAll move the table to a new generation, keys of the old one expire by ttl
+func (repo CachedRepository[T]) invalidateAll(ctx context.Context) {
+	AfterCommit(ctx, func() {
+		_ = repo.cache.Set(context.WithoutCancel(ctx), repo.generationKey(), strconv.FormatInt(time.Now().UnixNano(), 10), 0)
+	})
+}
@@ -0,0 +1,271 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+
+	defaultCursorPerPage = 15
+)
+
+/*
+CursorQuery for keyset pagination.
+  - Cursor: opaque value taken from CursorResult, empty for the first page
+  - Sort: sort columns of the model, primary key is appended as tie-breaker
+*/
+type CursorQuery struct {
+	Cursor  string
+	PerPage int
+	Sort    []OrderBy
+}
+
+type CursorResult struct {
+	Next     string
+	Previous string
+}
+
+type cursorToken struct {
+	Direction string            `json:"d"`
+	Columns   []string          `json:"c"`
+	Values    []json.RawMessage `json:"v"`
+}
+
+type cursorField struct {
+	column clause.Column
+	desc   bool
+	field  *schema.Field
+}
+
+func (repo GenericRepository[T]) FindCursorByExpression(
+	ctx context.Context,
+	cond []clause.Expression,
+	paginate CursorQuery,
+) ([]T, CursorResult, error) {
+	return repo.FindCursorByExpressionJoin(ctx, cond, paginate, nil, nil)
+}
+
+func (repo GenericRepository[T]) FindCursorByExpressionJoin(
+	ctx context.Context,
+	cond []clause.Expression,
+	paginate CursorQuery,
+	join []string,
+	preload []string,
+) ([]T, CursorResult, error) {
+	fields, err := repo.cursorFields(paginate.Sort)
+	if err != nil {
+		return nil, CursorResult{}, err
+	}
+
+	token, values, err := decodeCursor(paginate.Cursor, fields)
+	if err != nil {
+		return nil, CursorResult{}, err
+	}
+
+	perPage := paginate.PerPage
+	if perPage <= 0 {
+		perPage = defaultCursorPerPage
+	}
+
+	var result []T
+	db := repo.conn(ctx).
+		Model(&result)
+
+	if len(cond) > 0 {
+		db = db.Clauses(clause.Where{Exprs: cond})
+	}
+
+	for _, j := range join {
+		db = db.Joins(j)
+	}
+
+	for _, p := range preload {
+		db = db.Preload(p)
+	}
+
+	backward := token != nil && token.Direction == cursorPrev
+	if token != nil {
+		db = db.Where(keysetExpression(fields, values, backward))
+	}
+
+	for _, f := range fields {
+		db = db.Order(clause.OrderByColumn{Column: f.column, Desc: f.desc != backward})
+	}
+
+	err = db.Limit(perPage + 1).
+		Find(&result).Error
+	if err != nil {
+		return nil, CursorResult{}, err
+	}
+
+	hasMore := len(result) > perPage
+	if hasMore {
+		result = result[:perPage]
+	}
+	if backward {
+		slices.Reverse(result)
+	}
+
+	if len(result) == 0 {
+		return result, CursorResult{}, nil
+	}
+
+	var cursor CursorResult
+	first, last := &result[0], &result[len(result)-1]
+	switch {
+	case backward:
+		cursor.Next, err = encodeCursor(ctx, fields, last, cursorNext)
+		if err == nil && hasMore {
+			cursor.Previous, err = encodeCursor(ctx, fields, first, cursorPrev)
+		}
+	default:
+		if hasMore {
+			cursor.Next, err = encodeCursor(ctx, fields, last, cursorNext)
+		}
+		if err == nil && token != nil {
+			cursor.Previous, err = encodeCursor(ctx, fields, first, cursorPrev)
+		}
+	}
+
+	return result, cursor, err
+}
+
+func (repo GenericRepository[T]) cursorFields(sorts []OrderBy) ([]cursorField, error) {
+	sch, err := repo.parseSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := repo.model.TableName()
+	var fields = make([]cursorField, 0, len(sorts)+1)
+	for _, s := range sorts {
+		_, colName := getColNameStr(s.Column)
+		field := sch.LookUpField(colName)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("cursor sort column %s is not part of %s", s.Column, tableName)
+		}
+		fields = append(fields, cursorField{
+			column: clause.Column{Table: tableName, Name: field.DBName},
+			desc:   s.Desc,
+			field:  field,
+		})
+	}
+
+	// primary key as tie-breaker, so every row has a unique position
+	pk := sch.PrioritizedPrimaryField
+	if !slices.ContainsFunc(fields, func(f cursorField) bool { return f.field == pk }) {
+		var desc bool
+		if len(fields) > 0 {
+			desc = fields[len(fields)-1].desc
+		}
+		fields = append(fields, cursorField{
+			column: clause.Column{Table: tableName, Name: pk.DBName},
+			desc:   desc,
+			field:  pk,
+		})
+	}
+
+	return fields, nil
+}
+
+/*
+keysetExpression build seek condition for sort (a, b, c):
+
+	a > ? OR (a = ? AND b > ?) OR (a = ? AND b = ? AND c > ?)
+*/
+func keysetExpression(fields []cursorField, values []interface{}, backward bool) clause.Expression {
+	var branches = make([]clause.Expression, len(fields))
+	for i, f := range fields {
+		var exps = make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			exps = append(exps, clause.Eq{Column: fields[j].column, Value: values[j]})
+		}
+
+		if f.desc != backward {
+			exps = append(exps, clause.Lt{Column: f.column, Value: values[i]})
+		} else {
+			exps = append(exps, clause.Gt{Column: f.column, Value: values[i]})
+		}
+		branches[i] = clause.And(exps...)
+	}
+
+	if len(branches) == 1 {
+		return branches[0]
+	}
+	return clause.Or(branches...)
+}
+
+func encodeCursor(ctx context.Context, fields []cursorField, row interface{}, direction string) (string, error) {
+	token := cursorToken{
+		Direction: direction,
+		Columns:   make([]string, len(fields)),
+		Values:    make([]json.RawMessage, len(fields)),
+	}
+	rowVal := reflect.ValueOf(row).Elem()
+	for i, f := range fields {
+		val, _ := f.field.ValueOf(ctx, rowVal)
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		token.Columns[i] = f.field.DBName
+		token.Values[i] = raw
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string, fields []cursorField) (*cursorToken, []interface{}, error) {
+	if cursor == "" {
+		return nil, nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	var token cursorToken
+	if err = json.Unmarshal(data, &token); err != nil {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	if (token.Direction != cursorNext && token.Direction != cursorPrev) ||
+		len(token.Values) != len(fields) ||
+		len(token.Columns) != len(fields) {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	var values = make([]interface{}, len(fields))
+	for i, f := range fields {
+		if token.Columns[i] != f.field.DBName {
+			return nil, nil, ErrInvalidCursor
+		}
+
+		val := reflect.New(f.field.FieldType)
+		if err = json.Unmarshal(token.Values[i], val.Interface()); err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+		values[i] = val.Elem().Interface()
+	}
+
+	return &token, values, nil
+}
@@ -16,6 +16,10 @@ import (
 	"strings"
 )
 
+/*
+CustomORM legacy builder on raw SQL, Update and Store are not tenant scoped nor stamped (see TenantScope),
+use GenericRepository for tenant scoped entity.
+*/
 type CustomORM struct {
 	db           *gorm.DB
 	currSelected []string
@@ -30,7 +34,7 @@ func NewCustomeORM(db *gorm.DB) *CustomORM {
 }
 
 func (repo *CustomORM) WithContext(ctx context.Context) *CustomORM {
-	return &CustomORM{db: repo.db.WithContext(ctx)}
+	return &CustomORM{db: Conn(ctx, repo.db)}
 }
 
 func PascalToSnake(input string) string {
@@ -199,12 +203,25 @@ func (repo *CustomORM) Update(model interface{}) *CustomORM {
 		}
 		countCols++
 	}
-	execQuery += fmt.Sprintf(" WHERE id = uuid_to_bin('%s');", sVal.FieldByName("ID").Interface())
-
-	return &CustomORM{
-		db: repo.db.
-			Exec(execQuery),
+	id := sVal.FieldByName("ID").Interface()
+	execQuery += " WHERE id = " + uuidPlaceholder(repo.db) + ";"
+
+	// before snapshot, update and audit row commit together
+	result := repo.db.Session(&gorm.Session{})
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		audit := &CustomORM{db: tx}
+		before := audit.auditBefore(model, tableName, id, colSorted)
+		result = tx.Exec(execQuery, fmt.Sprint(id))
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit.auditLog(model, AuditActionUpdate, id, colSorted, before)
+	})
+	if err != nil && result.Error == nil {
+		result.AddError(err)
 	}
+
+	return &CustomORM{db: result}
 }
 
 func (repo *CustomORM) extractTableName(sType reflect.Type) string {
@@ -227,19 +244,41 @@ func (repo *CustomORM) Store(model interface{}, ignoreFields ...string) *CustomO
 	}
 	tableName := repo.extractTableName(pType)
 
+	// id is generated here instead of uuid() in SQL, so it is known to the model and the audit row
+	id := uuid.New()
+	setModelID(model, id)
+
 	query := "INSERT INTO `" + tableName + "` " +
 		"( " +
 		"`id`, " + strings.Join(colsName, ", ") +
 		" ) " +
 		"values " +
-		"(uuid_to_bin(uuid()), " + strings.Join(placeHolder, ", ") + ");"
-
-	return &CustomORM{db: repo.db.
-		Exec(
-			query,
-			actualVal...,
-		).
-		Find(&model),
+		"(" + uuidPlaceholder(repo.db) + ", " + strings.Join(placeHolder, ", ") + ");"
+
+	result := repo.db.Session(&gorm.Session{})
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		result = tx.Exec(query, append([]interface{}{id.String()}, actualVal...)...)
+		if result.Error != nil {
+			return result.Error
+		}
+		return (&CustomORM{db: tx}).auditLog(model, AuditActionCreate, id, colsName, nil)
+	})
+	if err != nil && result.Error == nil {
+		result.AddError(err)
+	}
+
+	return &CustomORM{db: result}
+}
+
+// setModelID write the generated id to the uuid ID field of model passed by pointer
+func setModelID(model interface{}, id uuid.UUID) {
+	sVal := reflect.ValueOf(model)
+	if sVal.Kind() != reflect.Pointer {
+		return
+	}
+	field := sVal.Elem().FieldByName("ID")
+	if field.IsValid() && field.CanSet() && field.Type() == reflect.TypeOf(id) {
+		field.Set(reflect.ValueOf(id))
 	}
 }
 
@@ -14,6 +14,7 @@ type BaseRepository interface {
 	SetupConnection(db *gorm.DB)
 }
 
+// Deprecated: use UnitOfWork.WithinTransaction, repositories pick the transaction from ctx without SetupConnection
 func NewDBTransaction(db *gorm.DB, repos ...BaseRepository) DBTransaction {
 	var result = DBTransaction{
 		db:    db,
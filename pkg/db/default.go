@@ -1,8 +1,6 @@
 package db
 
 import (
-	"fmt"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"os"
@@ -19,41 +17,34 @@ func Default() (*gorm.DB, error) {
 	if logLevel == 0 {
 		logLevel = 2
 	}
-	var (
-		username string
-		password string
-		host     string
-		port     string
-		dbName   string
-	)
-	username = os.Getenv("MYSQL_USER")
-	password = os.Getenv("MYSQL_PASSWORD")
-	dbName = os.Getenv("MYSQL_DATABASE")
-	port = os.Getenv("MYSQL_PORT")
-
-	switch os.Getenv("ENVIRONMENT") {
-	case "development":
-		host = os.Getenv("MYSQL_HOST_DEV")
-	case "staging":
-		host = os.Getenv("MYSQL_HOST_STAG_DOCKER")
-	case "docker":
-		host = os.Getenv("MYSQL_HOST_DOCKER")
+	// rows per insert statement of BulkStore and BulkUpsert (env: DB_CREATE_BATCH_SIZE)
+	batchSize, _ := strconv.Atoi(os.Getenv("DB_CREATE_BATCH_SIZE"))
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	primary, replicas, err := dialectors(Driver())
+	if err != nil {
+		return nil, err
 	}
 
 	dbConn, err := gorm.Open(
-		mysql.Open(fmt.Sprintf(
-			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
-			username,
-			password,
-			host,
-			port,
-			dbName,
-		)),
+		primary,
 		&gorm.Config{
-			CreateBatchSize: 500,
+			CreateBatchSize: batchSize,
 			Logger:          logger.Default.LogMode(logger.LogLevel(logLevel)),
 		},
 	)
+	if err != nil {
+		return dbConn, err
+	}
+
+	pool := NewPoolConfig()
+	err = configurePool(dbConn, pool)
+	if err != nil {
+		return dbConn, err
+	}
+
+	err = useReplicas(dbConn, replicas, pool)
 
 	return dbConn, err
 
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Driver from env DB_DRIVER, default mysql
+func Driver() string {
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		return driver
+	}
+	return DriverMySQL
+}
+
+// uuidPlaceholder placeholder of uuid written to binary id column, uuid_to_bin is mysql only
+func uuidPlaceholder(dbConn *gorm.DB) string {
+	if dbConn.Dialector.Name() == DriverMySQL {
+		return "uuid_to_bin(?)"
+	}
+	return "?"
+}
+
+// dialectors return primary and replica connection of the driver
+func dialectors(driver string) (gorm.Dialector, []gorm.Dialector, error) {
+	switch driver {
+	case DriverMySQL:
+		primary, replicas := mysqlDialectors()
+		return primary, replicas, nil
+	case DriverPostgres:
+		primary, replicas := postgresDialectors()
+		return primary, replicas, nil
+	case DriverSQLite:
+		return sqliteDialector(), nil, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported DB_DRIVER %q, use %s, %s or %s", driver, DriverMySQL, DriverPostgres, DriverSQLite)
+}
+
+/*
+mysqlDialectors (env: MYSQL_USER, MYSQL_PASSWORD, MYSQL_DATABASE, MYSQL_PORT, MYSQL_REPLICA_HOSTS),
+host is chosen by ENVIRONMENT
+*/
+func mysqlDialectors() (gorm.Dialector, []gorm.Dialector) {
+	var (
+		username = os.Getenv("MYSQL_USER")
+		password = os.Getenv("MYSQL_PASSWORD")
+		dbName   = os.Getenv("MYSQL_DATABASE")
+		port     = os.Getenv("MYSQL_PORT")
+		host     string
+	)
+
+	switch os.Getenv("ENVIRONMENT") {
+	case "development":
+		host = os.Getenv("MYSQL_HOST_DEV")
+	case "staging":
+		host = os.Getenv("MYSQL_HOST_STAG_DOCKER")
+	case "docker":
+		host = os.Getenv("MYSQL_HOST_DOCKER")
+	}
+
+	dsn := func(address string) gorm.Dialector {
+		return mysql.Open(fmt.Sprintf(
+			"%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
+			username,
+			password,
+			address,
+			dbName,
+		))
+	}
+
+	var replicas []gorm.Dialector
+	for _, address := range replicaAddresses("MYSQL_REPLICA_HOSTS", port) {
+		replicas = append(replicas, dsn(address))
+	}
+
+	return dsn(host + ":" + port), replicas
+}
+
+/*
+postgresDialectors (env: POSTGRES_HOST, POSTGRES_PORT, POSTGRES_USER, POSTGRES_PASSWORD, POSTGRES_DATABASE,
+POSTGRES_SSLMODE, POSTGRES_REPLICA_HOSTS)
+*/
+func postgresDialectors() (gorm.Dialector, []gorm.Dialector) {
+	port := os.Getenv("POSTGRES_PORT")
+	if port == "" {
+		port = "5432"
+	}
+	sslMode := os.Getenv("POSTGRES_SSLMODE")
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	dsn := func(address string) gorm.Dialector {
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD")),
+			Host:     address,
+			Path:     os.Getenv("POSTGRES_DATABASE"),
+			RawQuery: url.Values{"sslmode": {sslMode}, "TimeZone": {"UTC"}}.Encode(),
+		}
+		return postgres.Open(u.String())
+	}
+
+	var replicas []gorm.Dialector
+	for _, address := range replicaAddresses("POSTGRES_REPLICA_HOSTS", port) {
+		replicas = append(replicas, dsn(address))
+	}
+
+	return dsn(os.Getenv("POSTGRES_HOST") + ":" + port), replicas
+}
+
+// sqliteDialector (env: SQLITE_PATH, default base_be.db), use "file::memory:?cache=shared" for local test
+func sqliteDialector() gorm.Dialector {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "base_be.db"
+	}
+	return sqlite.Open(path)
+}
@@ -0,0 +1,236 @@
+package db
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Comparable value accepted by range predicates (Gt, Lte, Between, ...)
+type Comparable interface {
+	cmp.Ordered | time.Time
+}
+
+var (
+	/**
+	Format input
+	- TableName.ColName
+	- ColName
+	Return TableName, ColumnName
+	*/
+	getColNameStr = func(col string) (string, string) {
+		var colName, tableName string
+		split := strings.Split(col, ".")
+		if len(split) == 2 {
+			tableName = split[0]
+			colName = split[1]
+			return tableName, colName
+		}
+		return "", col
+	}
+
+	// Column build table-qualified column from "table.col" or "col"
+	Column = func(col string) clause.Column {
+		tableName, colName := getColNameStr(col)
+		return clause.Column{Name: colName, Table: tableName}
+	}
+
+	// Search case-insensitive contains on every col, the same on every DB_DRIVER (LIKE is case-sensitive on postgres)
+	Search = func(val string, col ...string) clause.Expression {
+		var exps = make([]clause.Expression, len(col))
+		for i, c := range col {
+			exps[i] = clause.Expr{
+				SQL:  "LOWER(?) LIKE ?",
+				Vars: []interface{}{Column(c), "%" + strings.ToLower(val) + "%"},
+			}
+		}
+		return Or(exps...)
+	}
+
+	Like = func(pattern string, col string) clause.Expression {
+		return clause.Like{Column: Column(col), Value: pattern}
+	}
+
+	Equal = func(val interface{}, col string) clause.Expression {
+		return clause.Eq{
+			Column: Column(col),
+			Value:  val,
+		}
+	}
+
+	NotEqual = func(val interface{}, col string) clause.Expression {
+		return clause.Neq{
+			Column: Column(col),
+			Value:  val,
+		}
+	}
+
+	IsNull = func(col string) clause.Expression {
+		return clause.Eq{Column: Column(col), Value: nil}
+	}
+
+	IsNotNull = func(col string) clause.Expression {
+		return clause.Neq{Column: Column(col), Value: nil}
+	}
+
+	/*
+		ExpressionDateRange col accept "table.col" or "col",
+		table is used when col is not qualified yet
+	*/
+	ExpressionDateRange = func(start time.Time, end time.Time, col string, table string) clause.Expression {
+		column := Column(col)
+		if column.Table == "" {
+			column.Table = table
+		}
+		return And(
+			clause.Gte{
+				Column: column,
+				Value:  start.Format("2006-01-02 15:04:05"),
+			},
+			clause.Lte{
+				Column: column,
+				Value:  end.Format("2006-01-02 15:04:05"),
+			},
+		)
+	}
+
+	// Query collect expressions, nil expression (see When) is skipped
+	Query = func(exps ...clause.Expression) []clause.Expression {
+		return compact(exps)
+	}
+)
+
+func Gt[T Comparable](val T, col string) clause.Expression {
+	return clause.Gt{Column: Column(col), Value: val}
+}
+
+func Gte[T Comparable](val T, col string) clause.Expression {
+	return clause.Gte{Column: Column(col), Value: val}
+}
+
+func Lt[T Comparable](val T, col string) clause.Expression {
+	return clause.Lt{Column: Column(col), Value: val}
+}
+
+func Lte[T Comparable](val T, col string) clause.Expression {
+	return clause.Lte{Column: Column(col), Value: val}
+}
+
+func Between[T Comparable](start T, end T, col string) clause.Expression {
+	return clause.Expr{
+		SQL:  "? BETWEEN ? AND ?",
+		Vars: []interface{}{Column(col), start, end},
+	}
+}
+
+func InArray[T interface{}](val []T, col string) clause.Expression {
+	output := make([]interface{}, len(val))
+	for i, v := range val {
+		output[i] = v
+	}
+
+	return clause.IN{
+		Column: Column(col),
+		Values: output,
+	}
+}
+
+func NotInArray[T interface{}](val []T, col string) clause.Expression {
+	output := make([]interface{}, len(val))
+	for i, v := range val {
+		output[i] = v
+	}
+
+	return clause.Not(clause.IN{
+		Column: Column(col),
+		Values: output,
+	})
+}
+
+/*
+And group expressions, always wrapped in parentheses when it has more than one.
+
+	db.Or(
+		db.And(db.Equal("active", "status"), db.Gte(18, "age")),
+		db.IsNull("deleted_at"),
+	)
+*/
+func And(exps ...clause.Expression) clause.Expression {
+	exps = compact(exps)
+	switch len(exps) {
+	case 0:
+		return nil
+	case 1:
+		return exps[0]
+	}
+	return clause.AndConditions{Exprs: exps}
+}
+
+func Or(exps ...clause.Expression) clause.Expression {
+	exps = compact(exps)
+	switch len(exps) {
+	case 0:
+		return nil
+	case 1:
+		// single OR condition is joined using OR by gorm, keep it as plain expression
+		return exps[0]
+	}
+	return clause.OrConditions{Exprs: exps}
+}
+
+func Not(exps ...clause.Expression) clause.Expression {
+	exps = compact(exps)
+	if len(exps) == 0 {
+		return nil
+	}
+	return clause.Not(exps...)
+}
+
+// When return exp only if ok, to build optional filter inline
+func When(ok bool, exp clause.Expression) clause.Expression {
+	if !ok {
+		return nil
+	}
+	return exp
+}
+
+func compact(exps []clause.Expression) []clause.Expression {
+	var result = make([]clause.Expression, 0, len(exps))
+	for _, exp := range exps {
+		if exp != nil {
+			result = append(result, exp)
+		}
+	}
+	return result
+}
+
+/*
+================ ORDER BY ==============
+*/
+
+type OrderBy struct {
+	Column string
+	Desc   bool
+}
+
+func OrderAsc(col string) OrderBy {
+	return OrderBy{Column: col}
+}
+
+func OrderDesc(col string) OrderBy {
+	return OrderBy{Column: col, Desc: true}
+}
+
+func Order(order ...OrderBy) []OrderBy {
+	return order
+}
+
+func applyOrder(db *gorm.DB, order []OrderBy) *gorm.DB {
+	for _, o := range order {
+		db = db.Order(clause.OrderByColumn{Column: Column(o.Column), Desc: o.Desc})
+	}
+	return db
+}
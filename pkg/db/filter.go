@@ -0,0 +1,294 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operator of filter query string, filter[field][op]=value, eq when op is omitted
+const (
+	FilterEq    = "eq"
+	FilterNe    = "ne"
+	FilterGt    = "gt"
+	FilterGte   = "gte"
+	FilterLt    = "lt"
+	FilterLte   = "lte"
+	FilterIn    = "in"
+	FilterNotIn = "nin"
+	FilterLike  = "like"
+	FilterNull  = "null"
+)
+
+// Type of filter value, the query string is converted before it reach the database
+const (
+	FilterString = "string"
+	FilterInt    = "int"
+	FilterFloat  = "float"
+	FilterBool   = "bool"
+	FilterTime   = "time"
+)
+
+const maxFilterValues = 100
+
+var filterKeyPattern = regexp.MustCompile(`^filter\[([A-Za-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+/*
+FilterField one filterable field of FilterSpec.
+  - Column: "table.col" or "col", never taken from the request
+  - Type: FilterString (default), FilterInt, FilterFloat, FilterBool or FilterTime (RFC3339 or 2006-01-02)
+  - Operators: allowed operators, default FilterEq only
+*/
+type FilterField struct {
+	Column    string
+	Type      string
+	Operators []string
+}
+
+/*
+FilterSpec declare what a list endpoint accept from query string, keyed by the name used in the request.
+Field, operator or sort field that is not declared is a validation error, so column name never come from the client.
+
+	var userFilter = db.FilterSpec{
+		Fields: map[string]db.FilterField{
+			"status":    {Column: "status", Operators: []string{db.FilterEq, db.FilterIn}},
+			"email":     {Column: "email", Operators: []string{db.FilterEq, db.FilterLike}},
+			"createdAt": {Column: "created_at", Type: db.FilterTime, Operators: []string{db.FilterGte, db.FilterLte}},
+		},
+		Sort:        map[string]string{"createdAt": "created_at", "name": "full_name"},
+		DefaultSort: db.Order(db.OrderDesc("created_at")),
+	}
+
+	?filter[status][in]=active,invited&filter[createdAt][gte]=2024-01-01&sort=-createdAt,name
+*/
+type FilterSpec struct {
+	Fields      map[string]FilterField
+	Sort        map[string]string
+	DefaultSort []OrderBy
+}
+
+// Filter parsed query string, Conditions and Order go straight to GenericRepository
+type Filter struct {
+	Conditions []clause.Expression
+	Order      []OrderBy
+}
+
+/*
+Where and Sort apply the filter to custom query as gorm scope. Keep Sort out of Count,
+postgres rejects ORDER BY on aggregate without GROUP BY.
+
+	tx := conn.Table("users").Scopes(filter.Where)
+	err := tx.Count(&total).Error
+	err = tx.Scopes(filter.Sort).Limit(perPage).Find(&result).Error
+*/
+func (f Filter) Where(db *gorm.DB) *gorm.DB {
+	if len(f.Conditions) == 0 {
+		return db
+	}
+	return db.Clauses(clause.Where{Exprs: f.Conditions})
+}
+
+func (f Filter) Sort(db *gorm.DB) *gorm.DB {
+	return applyOrder(db, f.Order)
+}
+
+/*
+Parse read filter[field][op] and sort params of values, other params are ignored.
+Time without zone is read in loc (UTC when nil). Errors are keyed by the param, same shape as validator errors.
+*/
+func (s FilterSpec) Parse(values url.Values, loc *time.Location) (Filter, map[string][]string) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var (
+		result Filter
+		errs   = map[string][]string{}
+		keys   []string
+	)
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	// sorted so the same query string always build the same SQL
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		vals := values[key]
+
+		match := filterKeyPattern.FindStringSubmatch(key)
+		if match == nil {
+			errs[key] = append(errs[key], "filter must be filter[field] or filter[field][operator]")
+			continue
+		}
+
+		exp, err := s.expression(match[1], match[2], vals, loc)
+		if err != nil {
+			errs[key] = append(errs[key], err.Error())
+			continue
+		}
+		result.Conditions = append(result.Conditions, exp)
+	}
+
+	order, err := s.order(values.Get("sort"))
+	if err != nil {
+		errs["sort"] = append(errs["sort"], err.Error())
+	}
+	result.Order = order
+
+	if len(errs) > 0 {
+		return Filter{}, errs
+	}
+	return result, nil
+}
+
+func (s FilterSpec) expression(name string, op string, vals []string, loc *time.Location) (clause.Expression, error) {
+	field, ok := s.Fields[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not filterable", name)
+	}
+	if op == "" {
+		op = FilterEq
+	}
+
+	allowed := field.Operators
+	if len(allowed) == 0 {
+		allowed = []string{FilterEq}
+	}
+	if !slices.Contains(allowed, op) {
+		return nil, fmt.Errorf("operator %s is not allowed on %s, use one of %s", op, name, strings.Join(allowed, ", "))
+	}
+
+	raw := vals[len(vals)-1]
+	switch op {
+	case FilterNull:
+		isNull, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", name)
+		}
+		if isNull {
+			return IsNull(field.Column), nil
+		}
+		return IsNotNull(field.Column), nil
+	case FilterLike:
+		return contains(raw, field.Column), nil
+	case FilterIn, FilterNotIn:
+		var items []interface{}
+		for _, v := range vals {
+			for _, item := range strings.Split(v, ",") {
+				val, err := field.convert(item, loc)
+				if err != nil {
+					return nil, fmt.Errorf("%s %w", name, err)
+				}
+				items = append(items, val)
+			}
+		}
+		if len(items) > maxFilterValues {
+			return nil, fmt.Errorf("%s accept at most %d values", name, maxFilterValues)
+		}
+		if op == FilterNotIn {
+			return clause.Not(clause.IN{Column: Column(field.Column), Values: items}), nil
+		}
+		return clause.IN{Column: Column(field.Column), Values: items}, nil
+	}
+
+	val, err := field.convert(raw, loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", name, err)
+	}
+	col := Column(field.Column)
+	switch op {
+	case FilterNe:
+		return clause.Neq{Column: col, Value: val}, nil
+	case FilterGt:
+		return clause.Gt{Column: col, Value: val}, nil
+	case FilterGte:
+		return clause.Gte{Column: col, Value: val}, nil
+	case FilterLt:
+		return clause.Lt{Column: col, Value: val}, nil
+	case FilterLte:
+		return clause.Lte{Column: col, Value: val}, nil
+	case FilterEq:
+		return clause.Eq{Column: col, Value: val}, nil
+	default:
+		return nil, fmt.Errorf("operator %s is unknown", op)
+	}
+}
+
+func (f FilterField) convert(raw string, loc *time.Location) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	switch f.Type {
+	case FilterInt:
+		val, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return val, nil
+	case FilterFloat:
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return val, nil
+	case FilterBool:
+		val, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return val, nil
+	case FilterTime:
+		if val, err := time.Parse(time.RFC3339, raw); err == nil {
+			return val.UTC(), nil
+		}
+		val, err := time.ParseInLocation(time.DateOnly, raw, loc)
+		if err != nil {
+			return nil, fmt.Errorf("must be RFC3339 or 2006-01-02")
+		}
+		return val.UTC(), nil
+	default:
+		return raw, nil
+	}
+}
+
+// contains case-insensitive LIKE like Search, with % and _ of val matched literally
+func contains(val string, col string) clause.Expression {
+	escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.ToLower(val))
+	return clause.Expr{
+		SQL:  "LOWER(?) LIKE ? ESCAPE '!'",
+		Vars: []interface{}{Column(col), "%" + escaped + "%"},
+	}
+}
+
+// order parse "-createdAt,name", minus means descending
+func (s FilterSpec) order(sort string) ([]OrderBy, error) {
+	if strings.TrimSpace(sort) == "" {
+		return s.DefaultSort, nil
+	}
+
+	var result []OrderBy
+	for _, item := range strings.Split(sort, ",") {
+		item = strings.TrimSpace(item)
+		desc := strings.HasPrefix(item, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(item, "-"), "+")
+
+		col, ok := s.Sort[name]
+		if !ok {
+			var allowed = make([]string, 0, len(s.Sort))
+			for k := range s.Sort {
+				allowed = append(allowed, k)
+			}
+			slices.Sort(allowed)
+			return nil, fmt.Errorf("%s is not sortable, use one of %s", name, strings.Join(allowed, ", "))
+		}
+		result = append(result, OrderBy{Column: col, Desc: desc})
+	}
+	return result, nil
+}
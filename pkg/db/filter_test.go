@@ -0,0 +1,214 @@
+package db
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm/clause"
+)
+
+var testFilterSpec = FilterSpec{
+	Fields: map[string]FilterField{
+		"status": {Column: "status", Operators: []string{FilterEq, FilterIn, FilterNotIn}},
+		"email":  {Column: "users.email", Operators: []string{FilterLike}},
+		"age":    {Column: "age", Type: FilterInt},
+	},
+	Sort:        map[string]string{"createdAt": "created_at", "name": "full_name"},
+	DefaultSort: Order(OrderDesc("created_at")),
+}
+
+func TestFilterSpecExpression(t *testing.T) {
+	tooMany := strings.TrimSuffix(strings.Repeat("a,", maxFilterValues+1), ",")
+	atLimit := strings.TrimSuffix(strings.Repeat("a,", maxFilterValues), ",")
+
+	tests := []struct {
+		name    string
+		field   string
+		op      string
+		vals    []string
+		want    clause.Expression
+		wantErr string
+	}{
+		{
+			name:    "undeclared field",
+			field:   "password",
+			vals:    []string{"x"},
+			wantErr: "password is not filterable",
+		},
+		{
+			name:    "undeclared operator",
+			field:   "status",
+			op:      FilterLike,
+			vals:    []string{"x"},
+			wantErr: "operator like is not allowed on status, use one of eq, in, nin",
+		},
+		{
+			name:    "eq only when operators are omitted",
+			field:   "age",
+			op:      FilterGt,
+			vals:    []string{"1"},
+			wantErr: "operator gt is not allowed on age, use one of eq",
+		},
+		{
+			name:  "eq when operator is omitted",
+			field: "age",
+			vals:  []string{"21"},
+			want:  clause.Eq{Column: Column("age"), Value: int64(21)},
+		},
+		{
+			name:    "value of wrong type",
+			field:   "age",
+			vals:    []string{"old"},
+			wantErr: "age must be a number",
+		},
+		{
+			name:  "in across repeated params",
+			field: "status",
+			op:    FilterIn,
+			vals:  []string{"active,invited", "banned"},
+			want:  clause.IN{Column: Column("status"), Values: []interface{}{"active", "invited", "banned"}},
+		},
+		{
+			name:  "in at the limit",
+			field: "status",
+			op:    FilterIn,
+			vals:  []string{atLimit},
+		},
+		{
+			name:    "in over the limit",
+			field:   "status",
+			op:      FilterIn,
+			vals:    []string{tooMany},
+			wantErr: "status accept at most 100 values",
+		},
+		{
+			name:    "not in over the limit",
+			field:   "status",
+			op:      FilterNotIn,
+			vals:    []string{atLimit, "b"},
+			wantErr: "status accept at most 100 values",
+		},
+		{
+			name:  "like escape wildcard",
+			field: "email",
+			op:    FilterLike,
+			vals:  []string{"A_b%c!d"},
+			want: clause.Expr{
+				SQL:  "LOWER(?) LIKE ? ESCAPE '!'",
+				Vars: []interface{}{Column("users.email"), "%a!_b!%c!!d%"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testFilterSpec.expression(tt.field, tt.op, tt.vals, nil)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expression = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterSpecOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []OrderBy
+		wantErr string
+	}{
+		{
+			name: "default when empty",
+			sort: " ",
+			want: Order(OrderDesc("created_at")),
+		},
+		{
+			name: "declared fields",
+			sort: "-createdAt, +name",
+			want: []OrderBy{{Column: "created_at", Desc: true}, {Column: "full_name"}},
+		},
+		{
+			name:    "column name is not accepted",
+			sort:    "full_name",
+			wantErr: "full_name is not sortable, use one of createdAt, name",
+		},
+		{
+			name:    "undeclared field",
+			sort:    "name,-password",
+			wantErr: "password is not sortable, use one of createdAt, name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testFilterSpec.order(tt.sort)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("order = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterSpecParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		conditions int
+		wantErrs   []string
+	}{
+		{
+			name:       "valid query",
+			query:      "filter[status][in]=active,invited&filter[age]=21&sort=-createdAt&page=2",
+			conditions: 2,
+		},
+		{
+			name:     "malformed key",
+			query:    "filter[status][IN]=active",
+			wantErrs: []string{"filter[status][IN]"},
+		},
+		{
+			name:     "every invalid param is reported",
+			query:    "filter[password]=x&filter[status][gt]=a&sort=password",
+			wantErrs: []string{"filter[password]", "filter[status][gt]", "sort"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, errs := testFilterSpec.Parse(values, nil)
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("errors = %v, want keys %v", errs, tt.wantErrs)
+			}
+			for _, key := range tt.wantErrs {
+				if len(errs[key]) == 0 {
+					t.Fatalf("errors = %v, missing key %s", errs, key)
+				}
+			}
+			if len(got.Conditions) != tt.conditions {
+				t.Fatalf("conditions = %d, want %d", len(got.Conditions), tt.conditions)
+			}
+		})
+	}
+}
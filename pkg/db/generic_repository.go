@@ -3,10 +3,12 @@ package db
 import (
 	"context"
 	"fmt"
+	"iter"
 	"reflect"
 	"strings"
 	"time"
 
+	"golang.org/x/exp/slices"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
@@ -21,6 +23,23 @@ func (repo *GenericRepository[T]) SetupConnection(db *gorm.DB) {
 	repo.db = db
 }
 
+// conn use transaction from ctx (see UnitOfWork) when exists
+func (repo GenericRepository[T]) conn(ctx context.Context) *gorm.DB {
+	return Conn(ctx, repo.db)
+}
+
+// parseSchema return model schema, model without primary key is rejected
+func (repo GenericRepository[T]) parseSchema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: repo.db}
+	if err := stmt.Parse(&repo.model); err != nil {
+		return nil, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no primary key", repo.model.TableName())
+	}
+	return stmt.Schema, nil
+}
+
 func NewGenericeRepoPointr[T schema.Tabler](db *gorm.DB, model T) *GenericRepository[T] {
 	return &GenericRepository[T]{
 		db:    db,
@@ -35,87 +54,6 @@ func NewGenericeRepo[T schema.Tabler](db *gorm.DB, table T) GenericRepository[T]
 	}
 }
 
-var (
-	/**
-	Format input
-	- TableName.ColName
-	- ColName
-	Return TableName, ColumnName
-	*/
-	getColNameStr = func(col string) (string, string) {
-		var colName, tableName string
-		split := strings.Split(col, ".")
-		if len(split) == 2 {
-			tableName = split[0]
-			colName = split[1]
-			return tableName, colName
-		}
-		return "", col
-	}
-
-	Search = func(val string, col ...string) clause.Expression {
-		var exps = make([]clause.Expression, len(col))
-		for i, c := range col {
-			tableName, colName := getColNameStr(c)
-			exps[i] = clause.Like{
-				Column: clause.Column{Name: colName, Table: tableName},
-				Value:  "%" + val + "%",
-			}
-		}
-		return clause.Or(exps...)
-	}
-
-	Equal = func(val interface{}, col string) clause.Expression {
-		tableName, colName := getColNameStr(col)
-		return clause.Eq{
-			Column: clause.Column{Name: colName, Table: tableName},
-			Value:  val,
-		}
-
-	}
-	ExpressionDateRange = func(start time.Time, end time.Time, col string, table string) clause.Expression {
-		return clause.And(
-			clause.Gte{
-				Column: clause.Column{Name: col},
-				Value:  start.Format("2006-01-02 15:04:05"),
-			},
-			clause.Lte{
-				Column: clause.Column{Name: col},
-				Value:  end.Format("2006-01-02 15:04:05"),
-			},
-		)
-	}
-	Query = func(exps ...clause.Expression) []clause.Expression {
-		return exps
-	}
-)
-
-func InArray[T interface{}](val []T, col string) clause.Expression {
-	tableName, colName := getColNameStr(col)
-	output := make([]interface{}, len(val))
-	for i, v := range val {
-		output[i] = v
-	}
-
-	return clause.IN{
-		Column: clause.Column{Name: colName, Table: tableName},
-		Values: output,
-	}
-}
-
-func NotInArray[T interface{}](val []T, col string) clause.Expression {
-	tableName, colName := getColNameStr(col)
-	output := make([]interface{}, len(val))
-	for i, v := range val {
-		output[i] = v
-	}
-
-	return clause.Not(clause.IN{
-		Column: clause.Column{Name: colName, Table: tableName},
-		Values: output,
-	})
-}
-
 type PaginationQuery struct {
 	PerPage int
 	Page    int
@@ -125,9 +63,12 @@ type GenericRepositoryInterface[T any] interface {
 	FindAllByExpression(
 		ctx context.Context,
 		expression []clause.Expression,
+		order ...OrderBy,
 	) ([]T, error)
 	FindAll(ctx context.Context) ([]T, error)
-	FindPagedByExpression(ctx context.Context, cond []clause.Expression, paginate PaginationQuery) ([]T, int, error)
+	FindInBatches(ctx context.Context, cond []clause.Expression, size int, fn func(batch []T) error) error
+	IterateByExpression(ctx context.Context, cond []clause.Expression, size int) iter.Seq2[T, error]
+	FindPagedByExpression(ctx context.Context, cond []clause.Expression, paginate PaginationQuery, order ...OrderBy) ([]T, int, error)
 	FindPagedByExpressionAndPreloadConditioned(
 		ctx context.Context,
 		cond []clause.Expression,
@@ -135,13 +76,15 @@ type GenericRepositoryInterface[T any] interface {
 		joins []string,
 		preload []PreloadWithCondition,
 		expType int,
+		order ...OrderBy,
 	) ([]T, int, error)
-	FindAllByExpressionAndPreloadConditioned(ctx context.Context, cond []clause.Expression, joins []string, preload []PreloadWithCondition) ([]T, error)
+	FindAllByExpressionAndPreloadConditioned(ctx context.Context, cond []clause.Expression, joins []string, preload []PreloadWithCondition, order ...OrderBy) ([]T, error)
 	FindAllByExpressionAndJoin(
 		ctx context.Context,
 		cond []clause.Expression,
 		join []string,
 		preload []string,
+		order ...OrderBy,
 	) ([]T, error)
 	FindAllByScopeAndJoin(
 		ctx context.Context,
@@ -152,32 +95,54 @@ type GenericRepositoryInterface[T any] interface {
 	CountByExpression(ctx context.Context, exp []clause.Expression) (int, error)
 	CountByExpressionAndJoin(ctx context.Context, exp []clause.Expression, join []string) (int, error)
 	SumByExpression(ctx context.Context, col string, exp []clause.Expression) (int, error)
+	AggregateByExpression(ctx context.Context, dest interface{}, cond []clause.Expression, query AggregateQuery) error
 	Update(ctx context.Context, data T) error
 	UpdateSelectedCols(ctx context.Context, data T, columns ...string) error
 	BulkStore(ctx context.Context, data []T) ([]T, error)
+	BulkUpsert(ctx context.Context, data []T, query UpsertQuery) (UpsertResult, error)
 	DeleteByExpression(ctx context.Context, exp []clause.Expression) error
 	StoreExclude(ctx context.Context, data T, ignore ...string) (T, error)
 	Store(ctx context.Context, data T) (T, error)
 	DeleteByID(ctx context.Context, id uint) error
 	Delete(ctx context.Context, data T) error
 	BulkDelete(ctx context.Context, data []T) error
+	Restore(ctx context.Context, id interface{}) error
+	RestoreByExpression(ctx context.Context, exp []clause.Expression) error
+	FindWithTrashed(ctx context.Context, cond []clause.Expression, order ...OrderBy) ([]T, error)
+	FindOneByIDWithTrashed(ctx context.Context, id interface{}) (T, error)
+	PurgeOlderThan(ctx context.Context, before time.Time) (int, error)
 	FindOneByID(ctx context.Context, id interface{}) (T, error)
 	FindOneByExpressionAndJoin(
 		ctx context.Context,
 		cond []clause.Expression,
 		joins []string,
 		preload []string,
+		order ...OrderBy,
 	) (T, error)
 	FindOneByExpression(
 		ctx context.Context,
 		cond []clause.Expression,
+		order ...OrderBy,
 	) (T, error)
-	FindPagedByExpressionJoin(ctx context.Context, cond []clause.Expression, paginate PaginationQuery, join []string, preload []string, expType int) ([]T, int, error)
+	FindPagedByExpressionJoin(ctx context.Context, cond []clause.Expression, paginate PaginationQuery, join []string, preload []string, expType int, order ...OrderBy) ([]T, int, error)
 	FindAllByExpressionPaginate(
 		ctx context.Context,
 		paginate PaginationQuery,
 		cond []clause.Expression,
+		order ...OrderBy,
 	) ([]T, int, error)
+	FindCursorByExpression(
+		ctx context.Context,
+		cond []clause.Expression,
+		paginate CursorQuery,
+	) ([]T, CursorResult, error)
+	FindCursorByExpressionJoin(
+		ctx context.Context,
+		cond []clause.Expression,
+		paginate CursorQuery,
+		join []string,
+		preload []string,
+	) ([]T, CursorResult, error)
 	BulkUpdateSelectedColumn(ctx context.Context, children []T, fields ...string) error
 	IsExistCondition(
 		ctx context.Context,
@@ -200,11 +165,13 @@ type GenericRepositoryInterface[T any] interface {
 		ctx context.Context,
 		entity interface{},
 		cond []clause.Expression,
+		order ...OrderBy,
 	) error
 	FindAllByExpSelection(
 		ctx context.Context,
 		entity interface{},
 		cond []clause.Expression,
+		order ...OrderBy,
 	) error
 	JoinAllByExpSelection(
 		ctx context.Context,
@@ -221,25 +188,51 @@ type GenericRepositoryInterface[T any] interface {
 }
 
 func (repo GenericRepository[T]) BulkUpdateSelectedColumn(ctx context.Context, children []T, fields ...string) error {
-	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		for _, child := range children {
-			err := tx.Select(fields).
-				Updates(&child).Error
-			if err != nil {
-				return err
+	fields = repo.withVersionColumn(ctx, repo.withAuditColumns(ctx, fields))
+	return repo.withAuditLog(ctx, AuditActionUpdate, repo.byPrimaryKey(ctx, children), nil, func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, child := range children {
+				stampUpdated(ctx, &child)
+				err := repo.updateVersioned(ctx, tx, &child, func(db *gorm.DB) *gorm.DB {
+					return db.Select(fields).
+						Updates(&child)
+				})
+				if err != nil {
+					return err
+				}
 			}
-		}
-		return nil
+			return nil
+		})
 	})
 }
 
 func (repo GenericRepository[T]) Update(ctx context.Context, data T) error {
-	return repo.db.WithContext(ctx).Updates(&data).Error
+	stampUpdated(ctx, &data)
+	return repo.withAuditLog(ctx, AuditActionUpdate, repo.byPrimaryKey(ctx, []T{data}), nil, func(db *gorm.DB) error {
+		return repo.updateVersioned(ctx, db, &data, func(db *gorm.DB) *gorm.DB {
+			return db.Updates(&data)
+		})
+	})
+}
+
+// withAuditColumns keep updated_at/updated_by in partial update of Auditable entity
+func (repo GenericRepository[T]) withAuditColumns(ctx context.Context, columns []string) []string {
+	if _, ok := any(&repo.model).(Auditable); !ok || len(columns) == 0 || isAuditSkipped(ctx) {
+		return columns
+	}
+
+	var result = append([]string{}, columns...)
+	for _, col := range []string{"updated_at", "updated_by"} {
+		if !slices.Contains(result, col) {
+			result = append(result, col)
+		}
+	}
+	return result
 }
 
 func (repo GenericRepository[T]) CountByExpressionAndJoin(ctx context.Context, exp []clause.Expression, join []string) (int, error) {
 	var count int64
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&repo.model)
 
 	for _, j := range join {
@@ -257,77 +250,129 @@ func (repo GenericRepository[T]) CountByExpressionAndJoin(ctx context.Context, e
 
 func (repo GenericRepository[T]) CountByExpression(ctx context.Context, exp []clause.Expression) (int, error) {
 	var count int64
-	err := repo.db.WithContext(ctx).
-		Table(repo.model.TableName()).
+	err := repo.conn(ctx).
+		Model(&repo.model).
 		Clauses(clause.Where{Exprs: exp}).
 		Count(&count).Error
 
 	return int(count), err
 }
 
+// SumByExpression sum as int, decimal is truncated.
+//
+// Deprecated: use AggregateByExpression with Sum, it keeps the decimal
 func (repo GenericRepository[T]) SumByExpression(ctx context.Context, col string, exp []clause.Expression) (int, error) {
 	var summary int
-	err := repo.db.WithContext(ctx).
-		Table(repo.model.TableName()).
+	err := repo.conn(ctx).
+		Model(&repo.model).
 		Clauses(clause.Where{Exprs: exp}).
-		Select(fmt.Sprintf("coalesce(sum(%s), 0) as summary", col)).
+		Select("coalesce(sum(?), 0) as summary", Column(col)).
 		Find(&summary).Error
 
 	return summary, err
 }
 
 func (repo GenericRepository[T]) UpdateSelectedCols(ctx context.Context, data T, columns ...string) error {
-	return repo.db.WithContext(ctx).
-		Select(columns).
-		Updates(&data).Error
+	stampUpdated(ctx, &data)
+	return repo.withAuditLog(ctx, AuditActionUpdate, repo.byPrimaryKey(ctx, []T{data}), nil, func(db *gorm.DB) error {
+		return repo.updateVersioned(ctx, db, &data, func(db *gorm.DB) *gorm.DB {
+			return db.Select(repo.withVersionColumn(ctx, repo.withAuditColumns(ctx, columns))).
+				Updates(&data)
+		})
+	})
 }
 
 func (repo GenericRepository[T]) BulkStore(ctx context.Context, data []T) ([]T, error) {
-	err := repo.db.WithContext(ctx).
-		Create(&data).Error
+	for i := range data {
+		stampCreated(ctx, &data[i])
+	}
+
+	err := repo.withAuditLog(ctx, AuditActionCreate, nil, func() []T { return data }, func(db *gorm.DB) error {
+		return db.Create(&data).Error
+	})
 
 	return data, err
 }
 
 func (repo GenericRepository[T]) StoreExclude(ctx context.Context, data T, ignore ...string) (T, error) {
-	err := repo.db.WithContext(ctx).
-		Omit(ignore...).
-		Create(&data).Error
+	stampCreated(ctx, &data)
+	err := repo.withAuditLog(ctx, AuditActionCreate, nil, func() []T { return []T{data} }, func(db *gorm.DB) error {
+		return db.Omit(ignore...).
+			Create(&data).Error
+	})
 
 	return data, err
 }
 
 func (repo GenericRepository[T]) Store(ctx context.Context, data T) (T, error) {
-	err := repo.db.WithContext(ctx).
-		Create(&data).Error
+	stampCreated(ctx, &data)
+	err := repo.withAuditLog(ctx, AuditActionCreate, nil, func() []T { return []T{data} }, func(db *gorm.DB) error {
+		return db.Create(&data).Error
+	})
 
 	return data, err
 }
 
 func (repo GenericRepository[T]) DeleteByExpression(ctx context.Context, exp []clause.Expression) error {
-	return repo.db.WithContext(ctx).
-		Clauses(clause.Where{Exprs: exp}).
-		Table(repo.model.TableName()).
-		Delete(&repo.model).Error
+	scope := func(tx *gorm.DB) *gorm.DB {
+		return tx.Clauses(clause.Where{Exprs: exp})
+	}
+
+	return repo.withAuditLog(ctx, AuditActionDelete, scope, nil, func(db *gorm.DB) error {
+		if repo.isSoftDelete() {
+			return db.Model(&repo.model).
+				Clauses(clause.Where{Exprs: exp}).
+				UpdateColumns(repo.softDeleteColumns(ctx)).Error
+		}
+
+		return db.Clauses(clause.Where{Exprs: exp}).
+			Table(repo.model.TableName()).
+			Delete(&repo.model).Error
+	})
 }
 
 func (repo GenericRepository[T]) Delete(ctx context.Context, data T) error {
-	return repo.db.WithContext(ctx).Delete(&data).Error
+	return repo.withAuditLog(ctx, AuditActionDelete, repo.byPrimaryKey(ctx, []T{data}), nil, func(db *gorm.DB) error {
+		if repo.isSoftDelete() {
+			return db.Model(&data).
+				UpdateColumns(repo.softDeleteColumns(ctx)).Error
+		}
+
+		return db.Delete(&data).Error
+	})
 }
 
 func (repo GenericRepository[T]) DeleteByID(ctx context.Context, id uint) error {
-	return repo.db.WithContext(ctx).
-		Where("id = ?", id).
-		Delete(&repo.model).Error
+	scope := func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("id = ?", id)
+	}
+
+	return repo.withAuditLog(ctx, AuditActionDelete, scope, nil, func(db *gorm.DB) error {
+		if repo.isSoftDelete() {
+			return db.Model(&repo.model).
+				Where("id = ?", id).
+				UpdateColumns(repo.softDeleteColumns(ctx)).Error
+		}
+
+		return db.Where("id = ?", id).
+			Delete(&repo.model).Error
+	})
 }
 
 func (repo GenericRepository[T]) BulkDelete(ctx context.Context, data []T) error {
-	return repo.db.WithContext(ctx).Delete(&data).Error
+	return repo.withAuditLog(ctx, AuditActionDelete, repo.byPrimaryKey(ctx, data), nil, func(db *gorm.DB) error {
+		if repo.isSoftDelete() {
+			return db.Model(&data).
+				UpdateColumns(repo.softDeleteColumns(ctx)).Error
+		}
+
+		return db.Delete(&data).Error
+	})
 }
 
 func (repo GenericRepository[T]) FindAll(ctx context.Context) ([]T, error) {
 	var data []T
-	err := repo.db.WithContext(ctx).
+	err := repo.conn(ctx).
 		Find(&data).Error
 
 	return data, err
@@ -336,10 +381,13 @@ func (repo GenericRepository[T]) FindAll(ctx context.Context) ([]T, error) {
 func (repo GenericRepository[T]) FindAllByExpression(
 	ctx context.Context,
 	expression []clause.Expression,
+	order ...OrderBy,
 ) ([]T, error) {
 	var result []T
-	err := repo.db.WithContext(ctx).
-		Clauses(clause.Where{Exprs: expression}).
+	db := repo.conn(ctx).
+		Clauses(clause.Where{Exprs: expression})
+
+	err := applyOrder(db, order).
 		Find(&result).Error
 
 	return result, err
@@ -352,7 +400,7 @@ func (repo GenericRepository[T]) FindAllByScopeAndJoin(
 	preload []string,
 ) ([]T, error) {
 	var result []T
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result)
 
 	for _, sc := range scope {
@@ -376,9 +424,10 @@ func (repo GenericRepository[T]) FindOneByExpressionAndJoin(
 	cond []clause.Expression,
 	joins []string,
 	preload []string,
+	order ...OrderBy,
 ) (T, error) {
 	var result T
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result).
 		Clauses(clause.Where{Exprs: cond})
 
@@ -390,13 +439,14 @@ func (repo GenericRepository[T]) FindOneByExpressionAndJoin(
 		db = db.Joins(join)
 	}
 
-	err := db.First(&result).Error
+	err := applyOrder(db, order).
+		First(&result).Error
 	return result, err
 }
 
 func (repo GenericRepository[T]) FindOneByID(ctx context.Context, id interface{}) (T, error) {
 	var data T
-	err := repo.db.WithContext(ctx).
+	err := repo.conn(ctx).
 		First(&data, "id = ?", id).Error
 
 	return data, err
@@ -405,13 +455,15 @@ func (repo GenericRepository[T]) FindOneByID(ctx context.Context, id interface{}
 func (repo GenericRepository[T]) FindOneByExpression(
 	ctx context.Context,
 	cond []clause.Expression,
+	order ...OrderBy,
 ) (T, error) {
 	var result T
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result).
 		Clauses(clause.Where{Exprs: cond})
 
-	err := db.First(&result).Error
+	err := applyOrder(db, order).
+		First(&result).Error
 	return result, err
 }
 
@@ -424,13 +476,18 @@ func (repo GenericRepository[T]) FindPagedByExpression(
 	ctx context.Context,
 	cond []clause.Expression,
 	paginate PaginationQuery,
+	order ...OrderBy,
 ) ([]T, int, error) {
 	var result []T
 	var total int64
 
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result)
 
+	if len(cond) > 0 {
+		db = db.Clauses(clause.Where{Exprs: cond})
+	}
+
 	errCount := db.Count(&total).Error
 	if errCount != nil {
 		return nil, 0, errCount
@@ -439,14 +496,10 @@ func (repo GenericRepository[T]) FindPagedByExpression(
 	offset := paginate.PerPage * (paginate.Page - 1)
 	limit := paginate.PerPage
 
-	if len(cond) > 0 {
-		db = db.Clauses(clause.Where{Exprs: cond})
-	}
-
-	err := db.
-		Find(&result).
+	err := applyOrder(db, order).
 		Offset(offset).
-		Limit(limit).Error
+		Limit(limit).
+		Find(&result).Error
 
 	return result, int(total), err
 }
@@ -463,11 +516,12 @@ func (repo GenericRepository[T]) FindPagedByExpressionAndPreloadConditioned(
 	joins []string,
 	preload []PreloadWithCondition,
 	expType int,
+	order ...OrderBy,
 ) ([]T, int, error) {
 	var result []T
 	var total int64
 
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result)
 
 	db = repo.applyWhereClause(cond, expType, db)
@@ -492,7 +546,7 @@ func (repo GenericRepository[T]) FindPagedByExpressionAndPreloadConditioned(
 	offset := paginate.PerPage * (paginate.Page - 1)
 	limit := paginate.PerPage
 
-	err := db.
+	err := applyOrder(db, order).
 		Offset(offset).
 		Limit(limit).
 		Find(&result).
@@ -521,9 +575,10 @@ func (repo GenericRepository[T]) FindAllByExpressionAndPreloadConditioned(
 	cond []clause.Expression,
 	joins []string,
 	preload []PreloadWithCondition,
+	order ...OrderBy,
 ) ([]T, error) {
 	var result []T
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result)
 
 	if len(cond) > 0 {
@@ -542,7 +597,8 @@ func (repo GenericRepository[T]) FindAllByExpressionAndPreloadConditioned(
 		}
 	}
 
-	err := db.Find(&result).Error
+	err := applyOrder(db, order).
+		Find(&result).Error
 	return result, err
 }
 
@@ -551,9 +607,10 @@ func (repo GenericRepository[T]) FindAllByExpressionAndJoin(
 	cond []clause.Expression,
 	join []string,
 	preload []string,
+	order ...OrderBy,
 ) ([]T, error) {
 	var result []T
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result).Clauses(clause.Where{Exprs: cond})
 
 	for _, j := range join {
@@ -564,7 +621,8 @@ func (repo GenericRepository[T]) FindAllByExpressionAndJoin(
 		db = db.Preload(s)
 	}
 
-	err := db.Find(&result).Error
+	err := applyOrder(db, order).
+		Find(&result).Error
 	return result, err
 }
 
@@ -575,10 +633,11 @@ func (repo GenericRepository[T]) FindPagedByExpressionJoin(
 	join []string,
 	preload []string,
 	expType int,
+	order ...OrderBy,
 ) ([]T, int, error) {
 	var result []T
 	var total int64
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result)
 
 	db = repo.applyWhereClause(cond, expType, db)
@@ -599,9 +658,10 @@ func (repo GenericRepository[T]) FindPagedByExpressionJoin(
 	offset := paginate.PerPage * (paginate.Page - 1)
 	limit := paginate.PerPage
 
-	err := db.Find(&result).
+	err := applyOrder(db, order).
 		Limit(limit).
-		Offset(offset).Error
+		Offset(offset).
+		Find(&result).Error
 
 	return result, int(total), err
 }
@@ -610,10 +670,11 @@ func (repo GenericRepository[T]) FindAllByExpressionPaginate(
 	ctx context.Context,
 	paginate PaginationQuery,
 	cond []clause.Expression,
+	order ...OrderBy,
 ) ([]T, int, error) {
 	var result []T
 	var total int64
-	db := repo.db.WithContext(ctx).
+	db := repo.conn(ctx).
 		Model(&result).
 		Clauses(clause.Where{Exprs: cond})
 
@@ -625,9 +686,10 @@ func (repo GenericRepository[T]) FindAllByExpressionPaginate(
 	offset := paginate.PerPage * (paginate.Page - 1)
 	limit := paginate.PerPage
 
-	err := db.Find(&result).
+	err := applyOrder(db, order).
 		Limit(limit).
-		Offset(offset).Error
+		Offset(offset).
+		Find(&result).Error
 
 	return result, int(total), err
 }
@@ -636,7 +698,7 @@ func (repo GenericRepository[T]) IsExistCondition(
 	cond []clause.Expression,
 ) (bool, error) {
 	var total int64
-	err := repo.db.WithContext(ctx).
+	err := repo.conn(ctx).
 		Model(&repo.model).
 		Clauses(clause.Where{Exprs: cond}).
 		Count(&total).Error
@@ -648,7 +710,7 @@ func (repo GenericRepository[T]) IsExist(
 	ctx context.Context,
 	column string, val interface{}) (bool, error) {
 	var total int64
-	err := repo.db.WithContext(ctx).
+	err := repo.conn(ctx).
 		Model(&repo.model).
 		Clauses(clause.Where{Exprs: []clause.Expression{
 			clause.Eq{
@@ -729,8 +791,8 @@ func (repo GenericRepository[T]) FindOneByIDSelection(
 		return err
 	}
 
-	err = repo.db.WithContext(ctx).
-		Table(repo.model.TableName()).
+	err = repo.conn(ctx).
+		Model(&repo.model).
 		Select(selection).
 		First(entity, "id = ?", id).Error
 
@@ -745,16 +807,19 @@ func (repo GenericRepository[T]) FindOneByExpSelection(
 	ctx context.Context,
 	entity interface{},
 	cond []clause.Expression,
+	order ...OrderBy,
 ) error {
 	selection, err := repo.extractSelection(entity)
 	if err != nil {
 		return err
 	}
 
-	err = repo.db.WithContext(ctx).
-		Table(repo.model.TableName()).
+	db := repo.conn(ctx).
+		Model(&repo.model).
 		Select(selection).
-		Where(clause.Where{Exprs: cond}).
+		Clauses(clause.Where{Exprs: cond})
+
+	err = applyOrder(db, order).
 		First(entity).Error
 
 	return err
@@ -764,16 +829,19 @@ func (repo GenericRepository[T]) FindAllByExpSelection(
 	ctx context.Context,
 	entity interface{},
 	cond []clause.Expression,
+	order ...OrderBy,
 ) error {
 	selection, err := repo.extractSelection(entity)
 	if err != nil {
 		return err
 	}
 
-	err = repo.db.WithContext(ctx).
-		Table(repo.model.TableName()).
+	db := repo.conn(ctx).
+		Model(&repo.model).
 		Select(selection).
-		Where(clause.Where{Exprs: cond}).
+		Clauses(clause.Where{Exprs: cond})
+
+	err = applyOrder(db, order).
 		Find(entity).Error
 
 	return err
@@ -790,8 +858,8 @@ func (repo GenericRepository[T]) JoinAllByExpSelection(
 		return err
 	}
 
-	tx := repo.db.WithContext(ctx).
-		Table(repo.model.TableName()).
+	tx := repo.conn(ctx).
+		Model(&repo.model).
 		Select(selection)
 
 	for _, j := range joins {
@@ -799,7 +867,7 @@ func (repo GenericRepository[T]) JoinAllByExpSelection(
 	}
 
 	err = tx.
-		Where(clause.Where{Exprs: cond}).
+		Clauses(clause.Where{Exprs: cond}).
 		Find(entity).Error
 
 	return err
@@ -817,8 +885,8 @@ func (repo GenericRepository[T]) JoinOneByExpSelection(
 		return err
 	}
 
-	tx := repo.db.WithContext(ctx).
-		Table(repo.model.TableName()).
+	tx := repo.conn(ctx).
+		Model(&repo.model).
 		Select(selection)
 
 	for _, j := range joins {
@@ -826,7 +894,7 @@ func (repo GenericRepository[T]) JoinOneByExpSelection(
 	}
 
 	err = tx.
-		Where(clause.Where{Exprs: cond}).
+		Clauses(clause.Where{Exprs: cond}).
 		First(entity).Error
 
 	return err
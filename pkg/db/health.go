@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const healthTimeout = 2 * time.Second
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// PoolStats usage of the primary connection pool
+type PoolStats struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	WaitMs             int64 `json:"waitMs"`
+}
+
+type Health struct {
+	Status    string      `json:"status"`
+	LatencyMs int64       `json:"latencyMs"`
+	Error     string      `json:"error,omitempty"`
+	Pool      PoolStats   `json:"pool"`
+	Queries   *QueryStats `json:"queries,omitempty"`
+}
+
+// Ping the primary, bounded by 2 seconds when ctx has no deadline
+func Ping(ctx context.Context, dbConn *gorm.DB) error {
+	sqlDB, err := dbConn.DB()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, healthTimeout)
+		defer cancel()
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckHealth ping the primary and report the pool and the query stats of Instrument
+func CheckHealth(ctx context.Context, dbConn *gorm.DB) Health {
+	var health = Health{Status: HealthUp}
+
+	start := time.Now()
+	err := Ping(ctx, dbConn)
+	health.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		health.Status = HealthDown
+		health.Error = err.Error()
+	}
+
+	if sqlDB, err := dbConn.DB(); err == nil {
+		stats := sqlDB.Stats()
+		health.Pool = PoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitMs:             stats.WaitDuration.Milliseconds(),
+		}
+	}
+
+	if stats, ok := Stats(dbConn); ok {
+		health.Queries = &stats
+	}
+	return health
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"base-be-golang/pkg/logger"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	instrumentName        = "db:instrument"
+	instrumentStartKey    = "db:instrument_start"
+	defaultSlowQuery      = 200 * time.Millisecond
+	maxSlowQueryLogLength = 2000
+)
+
+// QueryStats of every statement since the start, see Stats
+type QueryStats struct {
+	Queries     uint64 `json:"queries"`
+	Errors      uint64 `json:"errors"`
+	SlowQueries uint64 `json:"slowQueries"`
+	TotalMs     int64  `json:"totalMs"`
+	MaxMs       int64  `json:"maxMs"`
+	AverageMs   int64  `json:"averageMs"`
+}
+
+/*
+Instrument gorm plugin that time every statement (create, query, update, delete, row and raw).
+Statement slower than DB_SLOW_QUERY_MS (default 200) is logged as warning with its SQL, the values
+are not logged so secret never reach the log. Durations are summed in Stats for the health endpoint.
+Raw(...).Scan and Rows are timed until the first row is ready, use Find to time the whole read.
+
+	err = dbConn.Use(db.NewInstrument(&reZero))
+*/
+type Instrument struct {
+	log       *logger.ReZero
+	threshold time.Duration
+
+	queries     atomic.Uint64
+	errors      atomic.Uint64
+	slowQueries atomic.Uint64
+	totalNs     atomic.Int64
+	maxNs       atomic.Int64
+}
+
+func NewInstrument(log *logger.ReZero) *Instrument {
+	threshold := defaultSlowQuery
+	if ms := envInt("DB_SLOW_QUERY_MS", 0); ms > 0 {
+		threshold = time.Duration(ms) * time.Millisecond
+	}
+	return &Instrument{log: log, threshold: threshold}
+}
+
+func (i *Instrument) Name() string {
+	return instrumentName
+}
+
+func (i *Instrument) Initialize(dbConn *gorm.DB) error {
+	callback := dbConn.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register(instrumentName+"_before_create", i.start),
+		callback.Create().After("gorm:create").Register(instrumentName+"_after_create", i.finish),
+		callback.Query().Before("gorm:query").Register(instrumentName+"_before_query", i.start),
+		callback.Query().After("gorm:query").Register(instrumentName+"_after_query", i.finish),
+		callback.Update().Before("gorm:update").Register(instrumentName+"_before_update", i.start),
+		callback.Update().After("gorm:update").Register(instrumentName+"_after_update", i.finish),
+		callback.Delete().Before("gorm:delete").Register(instrumentName+"_before_delete", i.start),
+		callback.Delete().After("gorm:delete").Register(instrumentName+"_after_delete", i.finish),
+		callback.Row().Before("gorm:row").Register(instrumentName+"_before_row", i.start),
+		callback.Row().After("gorm:row").Register(instrumentName+"_after_row", i.finish),
+		callback.Raw().Before("gorm:raw").Register(instrumentName+"_before_raw", i.start),
+		callback.Raw().After("gorm:raw").Register(instrumentName+"_after_raw", i.finish),
+	)
+}
+
+func (i *Instrument) start(tx *gorm.DB) {
+	tx.InstanceSet(instrumentStartKey, time.Now())
+}
+
+func (i *Instrument) finish(tx *gorm.DB) {
+	val, ok := tx.InstanceGet(instrumentStartKey)
+	if !ok {
+		return
+	}
+	start, ok := val.(time.Time)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+
+	i.queries.Add(1)
+	i.totalNs.Add(int64(elapsed))
+	for {
+		current := i.maxNs.Load()
+		if int64(elapsed) <= current || i.maxNs.CompareAndSwap(current, int64(elapsed)) {
+			break
+		}
+	}
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		i.errors.Add(1)
+	}
+
+	if elapsed < i.threshold {
+		return
+	}
+	i.slowQueries.Add(1)
+	if i.log == nil {
+		return
+	}
+
+	sql := tx.Statement.SQL.String()
+	if len(sql) > maxSlowQueryLogLength {
+		sql = sql[:maxSlowQueryLogLength] + "..."
+	}
+	i.log.Warnf("slow query %dms, rows %d, table %s: %s", elapsed.Milliseconds(), tx.Statement.RowsAffected, tx.Statement.Table, sql)
+}
+
+func (i *Instrument) Stats() QueryStats {
+	stats := QueryStats{
+		Queries:     i.queries.Load(),
+		Errors:      i.errors.Load(),
+		SlowQueries: i.slowQueries.Load(),
+		TotalMs:     time.Duration(i.totalNs.Load()).Milliseconds(),
+		MaxMs:       time.Duration(i.maxNs.Load()).Milliseconds(),
+	}
+	if stats.Queries > 0 {
+		stats.AverageMs = time.Duration(i.totalNs.Load() / int64(stats.Queries)).Milliseconds()
+	}
+	return stats
+}
+
+// Stats of the Instrument registered to dbConn, false when it is not registered
+func Stats(dbConn *gorm.DB) (QueryStats, bool) {
+	instrument, ok := dbConn.Config.Plugins[instrumentName].(*Instrument)
+	if !ok {
+		return QueryStats{}, false
+	}
+	return instrument.Stats(), true
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"reflect"
+
+	"gorm.io/gorm/clause"
+)
+
+const defaultIterateSize = 1000
+
+// errStopIterate end FindInBatches when the range loop breaks, never returned to caller
+var errStopIterate = errors.New("stop iterate")
+
+/*
+FindInBatches walk rows matching cond ordered by primary key, size rows at a time, so memory stays flat
+on a big table. Every batch is a new keyset query (primary key > last key), rows inserted behind the cursor
+while walking are not visited. Reads join the transaction of ctx (see UnitOfWork), otherwise go to a replica.
+Returning error from fn, or cancelling ctx, stop the walk with that error.
+
+	err := orderRepo.FindInBatches(ctx, db.Query(db.Equal("PAID", "status")), 500, func(orders []domain.Order) error {
+		return writer.Write(orders)
+	})
+*/
+func (repo GenericRepository[T]) FindInBatches(ctx context.Context, cond []clause.Expression, size int, fn func(batch []T) error) error {
+	sch, err := repo.parseSchema()
+	if err != nil {
+		return err
+	}
+	if size <= 0 {
+		size = defaultIterateSize
+	}
+
+	pk := clause.Column{Table: sch.Table, Name: sch.PrioritizedPrimaryField.DBName}
+	var last interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		db := repo.conn(ctx).
+			Clauses(clause.Where{Exprs: cond})
+		if last != nil {
+			db = db.Where(clause.Gt{Column: pk, Value: last})
+		}
+
+		var batch []T
+		err := db.Order(clause.OrderByColumn{Column: pk}).
+			Limit(size).
+			Find(&batch).Error
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < size {
+			return nil
+		}
+
+		last, _ = sch.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(&batch[len(batch)-1]).Elem())
+	}
+}
+
+/*
+IterateByExpression range-over-func form of FindInBatches, rows are fetched size at a time.
+The walk stops on break, error is yielded once as the last element.
+
+	for order, err := range orderRepo.IterateByExpression(ctx, conditions, 500) {
+		if err != nil {
+			return err
+		}
+		...
+	}
+*/
+func (repo GenericRepository[T]) IterateByExpression(ctx context.Context, cond []clause.Expression, size int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var stopped bool
+		err := repo.FindInBatches(ctx, cond, size, func(batch []T) error {
+			for _, row := range batch {
+				if !yield(row, nil) {
+					stopped = true
+					return errStopIterate
+				}
+			}
+			return nil
+		})
+		if err != nil && !stopped {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+)
+
+var ErrVersionConflict = errors.New("data has been changed by another request")
+
+/*
+OptimisticLock embed to the entity to opt-in version check on Update, UpdateSelectedCols and BulkUpdateSelectedColumn.
+The write only hit the row which still has the entity version and move it to the next version,
+stale write return ErrVersionConflict. Entity with zero version take the current version from database (last write wins).
+
+	type UserAdmin struct {
+		BaseEntity
+		db.OptimisticLock
+		FullName string
+	}
+*/
+type OptimisticLock struct {
+	Version uint `gorm:"column:version;not null;default:1" audit:"-" json:"version"`
+}
+
+type skipVersionCtxKey struct{}
+
+type Versioned interface {
+	GetVersion() uint
+	SetVersion(version uint)
+}
+
+func (receiver *OptimisticLock) GetVersion() uint {
+	return receiver.Version
+}
+
+func (receiver *OptimisticLock) SetVersion(version uint) {
+	receiver.Version = version
+}
+
+/*
+WithoutVersion mark write inside ctx as bookkeeping of the entity (e.g. auth code, otp, password hash),
+it is audited but does not check nor move the version, so the version held by client stays valid.
+*/
+func WithoutVersion(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipVersionCtxKey{}, true)
+}
+
+// isVersioned false on WithoutAudit and WithoutVersion write, e.g. last active should not invalidate the version held by client
+func (repo GenericRepository[T]) isVersioned(ctx context.Context) bool {
+	_, ok := any(&repo.model).(Versioned)
+	skip, _ := ctx.Value(skipVersionCtxKey{}).(bool)
+	return ok && !skip && !isAuditSkipped(ctx)
+}
+
+// withVersionColumn keep version in partial update of Versioned entity
+func (repo GenericRepository[T]) withVersionColumn(ctx context.Context, columns []string) []string {
+	if !repo.isVersioned(ctx) || len(columns) == 0 || slices.Contains(columns, "version") {
+		return columns
+	}
+	return append(append([]string{}, columns...), "version")
+}
+
+// lockVersion scope the write to the version carried by data, then move data to the next version
+func (repo GenericRepository[T]) lockVersion(ctx context.Context, db *gorm.DB, data *T) (*gorm.DB, error) {
+	entity := any(data).(Versioned)
+
+	current := entity.GetVersion()
+	if current == 0 {
+		var versions []uint
+		err := repo.byPrimaryKey(ctx, []T{*data})(onPrimary(db.Session(&gorm.Session{NewDB: true}))).
+			Model(&repo.model).
+			Pluck(repo.model.TableName()+".version", &versions).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, gorm.ErrRecordNotFound
+		}
+		current = versions[0]
+	}
+
+	entity.SetVersion(current + 1)
+	return db.Where(Equal(current, repo.model.TableName()+".version")), nil
+}
+
+// updateVersioned run update, no affected row means the version is stale
+func (repo GenericRepository[T]) updateVersioned(ctx context.Context, db *gorm.DB, data *T, update func(db *gorm.DB) *gorm.DB) error {
+	if !repo.isVersioned(ctx) {
+		return update(db).Error
+	}
+
+	db, err := repo.lockVersion(ctx, db, data)
+	if err != nil {
+		return err
+	}
+
+	tx := update(db)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return tx.Error
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PoolConfig connection pool of the primary and every replica
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+/*
+NewPoolConfig (env: DB_MAX_OPEN_CONNS default 25, DB_MAX_IDLE_CONNS default 10,
+DB_CONN_MAX_LIFETIME_MINUTES default 30, DB_CONN_MAX_IDLE_TIME_MINUTES default 5).
+Keep lifetime below the server timeout (mysql wait_timeout), so a closed connection is never reused.
+*/
+func NewPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    envInt("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    envInt("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: time.Duration(envInt("DB_CONN_MAX_LIFETIME_MINUTES", 30)) * time.Minute,
+		ConnMaxIdleTime: time.Duration(envInt("DB_CONN_MAX_IDLE_TIME_MINUTES", 5)) * time.Minute,
+	}
+}
+
+func configurePool(dbConn *gorm.DB, pool PoolConfig) error {
+	sqlDB, err := dbConn.DB()
+	if err != nil {
+		return err
+	}
+
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	return nil
+}
+
+func envInt(key string, defaultValue int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || val < 0 {
+		return defaultValue
+	}
+	return val
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type readYourWritesCtxKey struct{}
+
+/*
+ReadYourWrites pin every read inside ctx to the primary, for read which must see the write made just before it
+(replica may lag behind). Read inside UnitOfWork transaction is always on the primary.
+
+	ctx = db.ReadYourWrites(ctx)
+	article, err := articleRepo.FindOneByID(ctx, id)
+*/
+func ReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesCtxKey{}, true)
+}
+
+func isReadYourWrites(ctx context.Context) bool {
+	pinned, _ := ctx.Value(readYourWritesCtxKey{}).(bool)
+	return pinned
+}
+
+// onPrimary route statement to the primary, no-op when replica is not configured
+func onPrimary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write).
+		Session(&gorm.Session{})
+}
+
+// replicaAddresses read comma separated host or host:port (default port) from env
+func replicaAddresses(env string, port string) []string {
+	var addresses []string
+	for _, host := range strings.Split(os.Getenv(env), ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if !strings.Contains(host, ":") {
+			host = host + ":" + port
+		}
+		addresses = append(addresses, host)
+	}
+	return addresses
+}
+
+// useReplicas send query to random replica, write and transaction stay on the primary
+func useReplicas(dbConn *gorm.DB, replicas []gorm.Dialector, pool PoolConfig) error {
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	})
+	err := dbConn.Use(resolver)
+	if err != nil {
+		return err
+	}
+
+	// replica pool is only known after Use
+	resolver.
+		SetMaxOpenConns(pool.MaxOpenConns).
+		SetMaxIdleConns(pool.MaxIdleConns).
+		SetConnMaxLifetime(pool.ConnMaxLifetime).
+		SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	return nil
+}
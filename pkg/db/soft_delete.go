@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotSoftDelete = errors.New("model does not support soft delete")
+
+/*
+SoftDelete embed to the entity to opt-in soft delete on GenericRepository.
+Every finder skip deleted rows, and Delete* only mark deleted_at and deleted_by.
+
+	type Article struct {
+		BaseEntity
+		db.SoftDelete
+		Title string
+	}
+*/
+type SoftDelete struct {
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index" json:"deletedAt"`
+	DeletedBy string         `gorm:"column:deleted_by;type:varchar(100)" json:"deletedBy"`
+}
+
+type SoftDeletable interface {
+	GetDeletedAt() *time.Time
+}
+
+func (receiver *SoftDelete) GetDeletedAt() *time.Time {
+	if receiver.DeletedAt.Valid {
+		return &receiver.DeletedAt.Time
+	}
+	return nil
+}
+
+func (repo GenericRepository[T]) isSoftDelete() bool {
+	_, ok := any(&repo.model).(SoftDeletable)
+	return ok
+}
+
+func (repo GenericRepository[T]) softDeleteColumns(ctx context.Context) map[string]interface{} {
+	return map[string]interface{}{
+		"deleted_at": time.Now().UTC(),
+		"deleted_by": ActorFromContext(ctx),
+	}
+}
+
+func (repo GenericRepository[T]) Restore(ctx context.Context, id interface{}) error {
+	return repo.RestoreByExpression(ctx, []clause.Expression{
+		Equal(id, repo.model.TableName()+".id"),
+	})
+}
+
+// RestoreByExpression return gorm.ErrRecordNotFound when no deleted row match
+func (repo GenericRepository[T]) RestoreByExpression(ctx context.Context, exp []clause.Expression) error {
+	if !repo.isSoftDelete() {
+		return ErrNotSoftDelete
+	}
+
+	scope := func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped().
+			Clauses(clause.Where{Exprs: exp}).
+			Where(IsNotNull(repo.model.TableName() + ".deleted_at"))
+	}
+
+	var restored int64
+	err := repo.withAuditLog(ctx, AuditActionRestore, scope, nil, func(db *gorm.DB) error {
+		tx := scope(db.Model(&repo.model)).
+			UpdateColumns(map[string]interface{}{
+				"deleted_at": nil,
+				"deleted_by": "",
+			})
+		restored = tx.RowsAffected
+		return tx.Error
+	})
+	if err != nil {
+		return err
+	}
+	if restored == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// FindWithTrashed same as FindAllByExpression, including soft deleted rows
+func (repo GenericRepository[T]) FindWithTrashed(
+	ctx context.Context,
+	cond []clause.Expression,
+	order ...OrderBy,
+) ([]T, error) {
+	var result []T
+	db := repo.conn(ctx).
+		Unscoped()
+
+	if len(cond) > 0 {
+		db = db.Clauses(clause.Where{Exprs: cond})
+	}
+
+	err := applyOrder(db, order).
+		Find(&result).Error
+
+	return result, err
+}
+
+func (repo GenericRepository[T]) FindOneByIDWithTrashed(ctx context.Context, id interface{}) (T, error) {
+	var data T
+	err := repo.conn(ctx).
+		Unscoped().
+		First(&data, "id = ?", id).Error
+
+	return data, err
+}
+
+// PurgeOlderThan permanently delete rows soft deleted before the given time
+func (repo GenericRepository[T]) PurgeOlderThan(ctx context.Context, before time.Time) (int, error) {
+	if !repo.isSoftDelete() {
+		return 0, ErrNotSoftDelete
+	}
+
+	scope := func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped().
+			Where(IsNotNull(repo.model.TableName() + ".deleted_at")).
+			Where(Lt(before, repo.model.TableName()+".deleted_at"))
+	}
+
+	var purged int64
+	err := repo.withAuditLog(ctx, AuditActionDelete, scope, nil, func(db *gorm.DB) error {
+		tx := scope(db).
+			Delete(&repo.model)
+		purged = tx.RowsAffected
+		return tx.Error
+	})
+
+	return int(purged), err
+}
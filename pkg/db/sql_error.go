@@ -0,0 +1,181 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type SQLErrorKind int
+
+const (
+	SQLErrUnknown SQLErrorKind = iota
+	SQLErrDuplicateKey
+	SQLErrForeignKey
+	SQLErrNotNull
+	SQLErrDataTooLong
+	SQLErrInvalidValue
+	SQLErrDeadlock
+	SQLErrLockTimeout
+)
+
+/*
+SQLError driver error in driver-agnostic shape, see TranslateError.
+Constraint is the violated key / constraint name, Column the offending column, both filled only when the driver report it.
+*/
+type SQLError struct {
+	Kind       SQLErrorKind
+	Constraint string
+	Column     string
+	Err        error
+}
+
+func (e SQLError) Error() string {
+	return e.Err.Error()
+}
+
+func (e SQLError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	mysqlKeyPattern        = regexp.MustCompile(`for key '([^']+)'`)
+	mysqlColumnPattern     = regexp.MustCompile(`(?:for column|Column) '([^']+)'`)
+	mysqlConstraintPattern = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+	sqliteColumnPattern    = regexp.MustCompile(`constraint failed: ([^\s,]+)`)
+)
+
+// TranslateError classify mysql, postgres and sqlite error, ok false when err does not come from the database
+func TranslateError(err error) (SQLError, bool) {
+	if err == nil {
+		return SQLError{}, false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return translateMySQL(mysqlErr), true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return translatePostgres(pgErr), true
+	}
+
+	return translateSQLite(err)
+}
+
+func translateMySQL(err *mysql.MySQLError) SQLError {
+	result := SQLError{Err: err}
+	switch err.Number {
+	case 1062:
+		result.Kind = SQLErrDuplicateKey
+		// mysql 8 prefix the key with table name, e.g. users.uk_users_email
+		key := submatch(mysqlKeyPattern, err.Message)
+		result.Constraint = key[strings.LastIndex(key, ".")+1:]
+	case 1451, 1452:
+		result.Kind = SQLErrForeignKey
+		result.Constraint = submatch(mysqlConstraintPattern, err.Message)
+	case 1048:
+		result.Kind = SQLErrNotNull
+		result.Column = submatch(mysqlColumnPattern, err.Message)
+	case 1406:
+		result.Kind = SQLErrDataTooLong
+		result.Column = submatch(mysqlColumnPattern, err.Message)
+	case 1265, 1366:
+		result.Kind = SQLErrInvalidValue
+		result.Column = submatch(mysqlColumnPattern, err.Message)
+	case 1213:
+		result.Kind = SQLErrDeadlock
+	case 1205:
+		result.Kind = SQLErrLockTimeout
+	}
+	return result
+}
+
+func translatePostgres(err *pgconn.PgError) SQLError {
+	result := SQLError{Err: err, Constraint: err.ConstraintName, Column: err.ColumnName}
+	switch err.Code {
+	case "23505":
+		result.Kind = SQLErrDuplicateKey
+	case "23503":
+		result.Kind = SQLErrForeignKey
+	case "23502":
+		result.Kind = SQLErrNotNull
+	case "22001":
+		result.Kind = SQLErrDataTooLong
+	case "22P02", "22007", "22003":
+		result.Kind = SQLErrInvalidValue
+	case "40P01", "40001":
+		result.Kind = SQLErrDeadlock
+	case "55P03":
+		result.Kind = SQLErrLockTimeout
+	}
+	return result
+}
+
+/*
+translateSQLite read the code through json like gorm sqlite driver does,
+so the sqlite3.Error type (cgo only) is not imported here
+*/
+func translateSQLite(err error) (SQLError, bool) {
+	var code struct {
+		Code         int `json:"Code"`
+		ExtendedCode int `json:"ExtendedCode"`
+	}
+	var found bool
+	for e := err; e != nil && !found; e = errors.Unwrap(e) {
+		raw, marshalErr := json.Marshal(e)
+		if marshalErr != nil || !isSQLiteError(raw) {
+			continue
+		}
+		found = json.Unmarshal(raw, &code) == nil
+	}
+	if !found {
+		return SQLError{}, false
+	}
+
+	result := SQLError{Err: err}
+	switch code.ExtendedCode {
+	case 2067, 1555:
+		result.Kind = SQLErrDuplicateKey
+		result.Column = submatch(sqliteColumnPattern, err.Error())
+	case 787:
+		result.Kind = SQLErrForeignKey
+	case 1299:
+		result.Kind = SQLErrNotNull
+		result.Column = submatch(sqliteColumnPattern, err.Error())
+	}
+	switch code.Code {
+	case 5, 6:
+		result.Kind = SQLErrLockTimeout
+	case 18:
+		result.Kind = SQLErrDataTooLong
+	}
+	return result, true
+}
+
+// isSQLiteError sqlite3.Error is the only error with exactly Code, ExtendedCode and SystemErrno
+func isSQLiteError(raw []byte) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil || len(fields) != 3 {
+		return false
+	}
+	for _, key := range []string{"Code", "ExtendedCode", "SystemErrno"} {
+		if _, ok := fields[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func submatch(pattern *regexp.Regexp, message string) string {
+	match := pattern.FindStringSubmatch(message)
+	if len(match) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(match[1])
+}
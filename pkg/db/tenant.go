@@ -0,0 +1,168 @@
+package db
+
+import (
+	"base-be-golang/pkg/tenant"
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrTenantMismatch = errors.New("entity belongs to another tenant")
+
+/*
+TenantScope embed to the entity to opt-in tenant isolation, the tenant is taken from ctx (see tenant.FromContext).
+With tenant in ctx every query, update and delete of the entity get tenant_id = <tenant>, also through
+preload and join, and create stamp tenant_id. Without tenant in ctx nothing is added.
+Raw SQL is not scoped, filter tenant_id by hand there. CustomORM Update and Store are raw SQL too,
+do not use them for tenant scoped entity.
+
+Embed it before db.SoftDelete, so hard delete of soft deletable entity is scoped too,
+and lead the index of columns looked up by with tenant_id (e.g. tenant_id + email). Make it unique only
+for entity without SoftDelete, a deleted row keeps holding its values.
+
+	type Article struct {
+		BaseEntity
+		db.TenantScope
+		db.SoftDelete
+		Title string
+	}
+*/
+type TenantScope struct {
+	TenantID TenantID `gorm:"column:tenant_id;type:varchar(64);index" audit:"-" json:"-"`
+}
+
+func (receiver *TenantScope) GetTenantID() string {
+	return string(receiver.TenantID)
+}
+
+// TenantID column type of TenantScope, it adds the tenant clauses to every statement of the entity
+type TenantID string
+
+func (TenantID) QueryClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{tenantQueryClause{field: f}}
+}
+
+func (TenantID) UpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{tenantWriteClause{field: f}}
+}
+
+func (TenantID) DeleteClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{tenantWriteClause{field: f}}
+}
+
+func (TenantID) CreateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{tenantCreateClause{field: f}}
+}
+
+func statementContext(stmt *gorm.Statement) context.Context {
+	if stmt.Context == nil && stmt.DB != nil {
+		// join condition of preloaded relation is built on a bare statement
+		return stmt.DB.Statement.Context
+	}
+	return stmt.Context
+}
+
+type tenantQueryClause struct {
+	field *schema.Field
+}
+
+func (c tenantQueryClause) Name() string {
+	return ""
+}
+
+func (c tenantQueryClause) Build(clause.Builder) {
+}
+
+func (c tenantQueryClause) MergeClause(*clause.Clause) {
+}
+
+func (c tenantQueryClause) ModifyStatement(stmt *gorm.Statement) {
+	if _, ok := stmt.Clauses["tenant_enabled"]; ok {
+		return
+	}
+	id, ok := tenant.FromContext(statementContext(stmt))
+	if !ok {
+		return
+	}
+
+	// single OR condition is joined with OR by gorm, wrap it so the tenant stay mandatory (same as gorm soft delete)
+	if cl, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := cl.Expression.(clause.Where); ok && len(where.Exprs) >= 1 {
+			for _, exp := range where.Exprs {
+				if or, ok := exp.(clause.OrConditions); ok && len(or.Exprs) == 1 {
+					where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
+					cl.Expression = where
+					stmt.Clauses["WHERE"] = cl
+					break
+				}
+			}
+		}
+	}
+
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: c.field.DBName}, Value: id},
+	}})
+	stmt.Clauses["tenant_enabled"] = clause.Clause{}
+}
+
+type tenantWriteClause tenantQueryClause
+
+func (c tenantWriteClause) Name() string {
+	return ""
+}
+
+func (c tenantWriteClause) Build(clause.Builder) {
+}
+
+func (c tenantWriteClause) MergeClause(*clause.Clause) {
+}
+
+func (c tenantWriteClause) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.SQL.Len() == 0 {
+		tenantQueryClause(c).ModifyStatement(stmt)
+	}
+}
+
+type tenantCreateClause tenantQueryClause
+
+func (c tenantCreateClause) Name() string {
+	return ""
+}
+
+func (c tenantCreateClause) Build(clause.Builder) {
+}
+
+func (c tenantCreateClause) MergeClause(*clause.Clause) {
+}
+
+// ModifyStatement stamp tenant of ctx to row without tenant, row of another tenant fail with ErrTenantMismatch
+func (c tenantCreateClause) ModifyStatement(stmt *gorm.Statement) {
+	id, ok := tenant.FromContext(stmt.Context)
+	if !ok {
+		return
+	}
+
+	stamp := func(row reflect.Value) {
+		val, zero := c.field.ValueOf(stmt.Context, row)
+		if zero {
+			stmt.AddError(c.field.Set(stmt.Context, row, id))
+			return
+		}
+		if string(val.(TenantID)) != id {
+			stmt.AddError(ErrTenantMismatch)
+		}
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			stamp(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		stamp(stmt.ReflectValue)
+	}
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTxMaxRetry = 3
+	txRetryBackoff    = 50 * time.Millisecond
+)
+
+type (
+	txCtxKey      struct{}
+	txHooksCtxKey struct{}
+)
+
+// txHooks callbacks of the outermost transaction, run once it is committed
+type txHooks struct {
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+func (h *txHooks) run() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, fn := range h.afterCommit {
+		fn()
+	}
+}
+
+/*
+UnitOfWork run several repository calls in one transaction, the transaction is carried in the context
+so every repository (generic, custom, or CustomORM) created from the same connection join it.
+
+	err := uow.WithinTransaction(ctx, func(ctx context.Context) error {
+		article, err := articleRepo.Store(ctx, article)
+		if err != nil {
+			return err
+		}
+		_, err = tagRepo.BulkStore(ctx, tags)
+		return err
+	})
+*/
+type UnitOfWork struct {
+	db       *gorm.DB
+	maxRetry int
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	maxRetry, err := strconv.Atoi(os.Getenv("DB_TX_MAX_RETRY"))
+	if err != nil || maxRetry < 0 {
+		maxRetry = defaultTxMaxRetry
+	}
+
+	return UnitOfWork{
+		db:       db,
+		maxRetry: maxRetry,
+	}
+}
+
+/*
+WithinTransaction commit when fn return nil, rollback otherwise.
+  - nested call join the running transaction using savepoint, only the nested part is rolled back
+  - outermost transaction is retried on deadlock or lock wait timeout (env DB_TX_MAX_RETRY, default 3)
+*/
+func (uow UnitOfWork) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return Conn(ctx, uow.db).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txCtxKey{}, tx))
+		})
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		hooks := &txHooks{}
+		err = uow.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(context.WithValue(ctx, txCtxKey{}, tx), txHooksCtxKey{}, hooks))
+		})
+		if err == nil {
+			hooks.run()
+			return nil
+		}
+		if attempt >= uow.maxRetry || !isRetryableTxErr(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(txRetryBackoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+/*
+AfterCommit run fn once the transaction of ctx is committed, right away when ctx has no transaction.
+Hook of a rolled back transaction is dropped, hook registered inside a rolled back savepoint still run on commit.
+*/
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(txHooksCtxKey{}).(*txHooks); ok {
+		hooks.mu.Lock()
+		hooks.afterCommit = append(hooks.afterCommit, fn)
+		hooks.mu.Unlock()
+		return
+	}
+	fn()
+}
+
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txCtxKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
+/*
+Conn return running transaction from ctx, fallback to db. Use it in custom repository instead of db.WithContext(ctx).
+Read outside transaction go to a replica when configured, unless ctx is marked with ReadYourWrites.
+*/
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	if isReadYourWrites(ctx) {
+		return onPrimary(db.WithContext(ctx))
+	}
+	return db.WithContext(ctx)
+}
+
+func isRetryableTxErr(err error) bool {
+	sqlErr, ok := TranslateError(err)
+	return ok && (sqlErr.Kind == SQLErrDeadlock || sqlErr.Kind == SQLErrLockTimeout)
+}
@@ -0,0 +1,323 @@
+package db
+
+import (
+	"base-be-golang/pkg/tenant"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const defaultBatchSize = 500
+
+/*
+UpsertQuery conflict handling of BulkUpsert.
+  - Conflict: columns of the unique index hit by the insert, default primary key.
+    MySQL ignores it and reacts to every unique index of the table.
+  - Update: columns written on conflict, default every column except conflict, primary key,
+    created_*, deleted_*, tenant_id and version. updated_at/updated_by are added for Auditable entity
+  - Ignore: keep the existing row untouched (DO NOTHING), Update is not used
+  - BatchSize: rows per insert statement, default CreateBatchSize of the connection
+*/
+type UpsertQuery struct {
+	Conflict  []string
+	Update    []string
+	Ignore    bool
+	BatchSize int
+}
+
+/*
+UpsertBatch result of one insert statement, a conflicting row is Updated or Ignored (Ignore mode).
+Counts come from the lookup by Conflict columns, on MySQL a row that hits another unique index
+updates that row but is counted as Inserted.
+*/
+type UpsertBatch struct {
+	Inserted int
+	Updated  int
+	Ignored  int
+}
+
+type UpsertResult struct {
+	Batches  []UpsertBatch
+	Inserted int
+	Updated  int
+	Ignored  int
+}
+
+/*
+BulkUpsert insert data in batches, row that conflict with an existing one is updated or ignored.
+Each batch runs in its own transaction (savepoint inside UnitOfWork), a failed batch stop the import
+and the result hold the batches written before it. Existing rows are looked up by Conflict columns before
+the insert to tell inserted from updated, including soft deleted row which stays deleted.
+Rows of data with the same Conflict values are merged before the insert, the last one wins.
+Versioned entity moves to the next version on update, conflict with a row of another tenant fail with ErrTenantMismatch.
+Primary key of data is not reliable after the call, reload by the conflict columns when needed.
+
+	result, err := repo.BulkUpsert(ctx, users, db.UpsertQuery{
+		Conflict: []string{"email"},
+		Update:   []string{"full_name", "phone"},
+	})
+*/
+func (repo GenericRepository[T]) BulkUpsert(ctx context.Context, data []T, query UpsertQuery) (UpsertResult, error) {
+	sch, err := repo.parseSchema()
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	conflict, err := upsertConflictFields(sch, query.Conflict)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+	onConflict := repo.onConflict(ctx, sch, conflict, query)
+
+	batchSize := query.BatchSize
+	if batchSize <= 0 {
+		batchSize = repo.db.CreateBatchSize
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	for i := range data {
+		stampCreated(ctx, &data[i])
+	}
+	data = dedupeUpsert(ctx, conflict, data)
+
+	var result UpsertResult
+	for start := 0; start < len(data); start += batchSize {
+		end := start + batchSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		batch, err := repo.upsertBatch(ctx, sch, conflict, onConflict, data[start:end], query.Ignore)
+		if err != nil {
+			return result, fmt.Errorf("upsert batch %d: %w", len(result.Batches)+1, err)
+		}
+
+		result.Batches = append(result.Batches, batch)
+		result.Inserted += batch.Inserted
+		result.Updated += batch.Updated
+		result.Ignored += batch.Ignored
+	}
+
+	return result, nil
+}
+
+func upsertConflictFields(sch *schema.Schema, columns []string) ([]*schema.Field, error) {
+	if len(columns) == 0 {
+		return []*schema.Field{sch.PrioritizedPrimaryField}, nil
+	}
+
+	var fields = make([]*schema.Field, 0, len(columns))
+	for _, col := range columns {
+		_, name := getColNameStr(col)
+		f := sch.LookUpField(name)
+		if f == nil || f.DBName == "" {
+			return nil, fmt.Errorf("conflict column %s is not a column of %s", col, sch.Table)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func (repo GenericRepository[T]) onConflict(ctx context.Context, sch *schema.Schema, conflict []*schema.Field, query UpsertQuery) clause.OnConflict {
+	var result = clause.OnConflict{DoNothing: query.Ignore}
+	for _, f := range conflict {
+		result.Columns = append(result.Columns, clause.Column{Name: f.DBName})
+	}
+	if query.Ignore {
+		return result
+	}
+
+	update := query.Update
+	if len(update) == 0 {
+		for _, f := range sch.Fields {
+			if f.DBName == "" || f.PrimaryKey || slices.Contains(conflict, f) || strings.HasPrefix(f.DBName, "created_") ||
+				strings.HasPrefix(f.DBName, "deleted_") || f.DBName == "version" || f.DBName == "tenant_id" {
+				continue
+			}
+			update = append(update, f.DBName)
+		}
+	} else {
+		update = repo.withAuditColumns(ctx, update)
+	}
+	result.DoUpdates = clause.AssignmentColumns(update)
+
+	if repo.isVersioned(ctx) {
+		result.DoUpdates = append(result.DoUpdates, clause.Assignment{
+			Column: clause.Column{Name: "version"},
+			Value:  gorm.Expr("? + 1", clause.Column{Table: sch.Table, Name: "version"}),
+		})
+	}
+	return result
+}
+
+// upsertKey identify row by conflict values, the same way for input and loaded row
+func upsertKey(ctx context.Context, conflict []*schema.Field, row reflect.Value) string {
+	var values = make([]string, len(conflict))
+	for i, f := range conflict {
+		val, _ := f.ValueOf(ctx, row)
+		values[i] = fmt.Sprint(auditValue(val))
+	}
+	return strings.Join(values, "\x00")
+}
+
+/*
+dedupeUpsert keep the last row of every conflict key at the position of the first one,
+a statement can not touch the same row twice (postgres fail, mysql report two inserts).
+Row with zero primary key is new and never merged.
+*/
+func dedupeUpsert[T any](ctx context.Context, conflict []*schema.Field, data []T) []T {
+	var (
+		result = make([]T, 0, len(data))
+		seen   = make(map[string]int, len(data))
+	)
+	for i := range data {
+		row := reflect.ValueOf(&data[i]).Elem()
+		if hasZeroPrimaryKey(ctx, conflict, row) {
+			result = append(result, data[i])
+			continue
+		}
+
+		key := upsertKey(ctx, conflict, row)
+		if at, ok := seen[key]; ok {
+			result[at] = data[i]
+			continue
+		}
+		seen[key] = len(result)
+		result = append(result, data[i])
+	}
+	return result
+}
+
+func hasZeroPrimaryKey(ctx context.Context, conflict []*schema.Field, row reflect.Value) bool {
+	for _, f := range conflict {
+		if _, zero := f.ValueOf(ctx, row); f.PrimaryKey && zero {
+			return true
+		}
+	}
+	return false
+}
+
+// byConflictKey scope rows which have the same conflict values as rows
+func byConflictKey(ctx context.Context, sch *schema.Schema, conflict []*schema.Field, rows reflect.Value) clause.Expression {
+	if len(conflict) == 1 {
+		var values = make([]interface{}, rows.Len())
+		for i := range values {
+			values[i], _ = conflict[0].ValueOf(ctx, reflect.Indirect(rows.Index(i)))
+		}
+		return clause.IN{Column: clause.Column{Table: sch.Table, Name: conflict[0].DBName}, Values: values}
+	}
+
+	var exps = make([]clause.Expression, rows.Len())
+	for i := range exps {
+		var eq = make([]clause.Expression, len(conflict))
+		for j, f := range conflict {
+			val, _ := f.ValueOf(ctx, reflect.Indirect(rows.Index(i)))
+			eq[j] = clause.Eq{Column: clause.Column{Table: sch.Table, Name: f.DBName}, Value: val}
+		}
+		exps[i] = And(eq...)
+	}
+	return Or(exps...)
+}
+
+func (repo GenericRepository[T]) upsertBatch(
+	ctx context.Context,
+	sch *schema.Schema,
+	conflict []*schema.Field,
+	onConflict clause.OnConflict,
+	batch []T,
+	ignore bool,
+) (UpsertBatch, error) {
+	trail, err := repo.auditTrail()
+	if err != nil {
+		return UpsertBatch{}, err
+	}
+	if isAuditSkipped(ctx) {
+		trail = nil
+	}
+
+	var result UpsertBatch
+	err = repo.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		scope := byConflictKey(ctx, sch, conflict, reflect.ValueOf(batch))
+
+		// looked up across tenants, ON CONFLICT must not update the row of another tenant
+		var existing []T
+		err := tx.Session(&gorm.Session{NewDB: true, Context: tenant.Without(ctx)}).
+			Unscoped().
+			Where(scope).
+			Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		if id, ok := tenant.FromContext(ctx); ok {
+			for i := range existing {
+				if scoped, ok := any(&existing[i]).(interface{ GetTenantID() string }); ok && scoped.GetTenantID() != id {
+					return ErrTenantMismatch
+				}
+			}
+		}
+
+		var existKeys = make(map[string]bool, len(existing))
+		for i := range existing {
+			existKeys[upsertKey(ctx, conflict, reflect.ValueOf(&existing[i]).Elem())] = true
+		}
+		for i := range batch {
+			switch {
+			case !existKeys[upsertKey(ctx, conflict, reflect.ValueOf(&batch[i]).Elem())]:
+				result.Inserted++
+			case ignore:
+				result.Ignored++
+			default:
+				result.Updated++
+			}
+		}
+
+		// gorm assigns returned key by position, which is wrong once a row conflicts, keep the input intact
+		var rows = append([]T{}, batch...)
+		err = tx.Session(&gorm.Session{NewDB: true}).
+			Clauses(onConflict).
+			CreateInBatches(&rows, len(rows)).Error
+		if err != nil {
+			return err
+		}
+
+		if trail == nil {
+			return nil
+		}
+
+		var after []T
+		err = tx.Session(&gorm.Session{NewDB: true}).
+			Unscoped().
+			Where(scope).
+			Find(&after).Error
+		if err != nil {
+			return err
+		}
+
+		var created, updated []T
+		for i := range after {
+			if !existKeys[upsertKey(ctx, conflict, reflect.ValueOf(&after[i]).Elem())] {
+				created = append(created, after[i])
+			} else if !ignore {
+				updated = append(updated, after[i])
+			}
+		}
+
+		if !ignore {
+			err = trail.write(ctx, tx, AuditActionUpdate, reflect.ValueOf(existing), reflect.ValueOf(updated))
+			if err != nil {
+				return err
+			}
+		}
+		return trail.write(ctx, tx, AuditActionCreate, reflect.ValueOf([]T{}), reflect.ValueOf(created))
+	})
+
+	return result, err
+}
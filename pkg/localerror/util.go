@@ -1,6 +1,7 @@
 package localerror
 
 import (
+	"base-be-golang/pkg/db"
 	"base-be-golang/pkg/logger"
 	"errors"
 
@@ -69,6 +70,27 @@ func InvalidDataWithData(msg string, data map[string]string) error {
 	return InvalidDataError{Msg: msg, DataToTemplated: data}
 }
 
+// ConflictError stale write, the data has been changed since it was read
+type ConflictError struct {
+	Msg string
+}
+
+func (e ConflictError) Error() string {
+	return e.Msg
+}
+
+func IsConflict(err error) bool {
+	return err != nil && errors.As(err, &ConflictError{})
+}
+
+// Conflict translate db.ErrVersionConflict to ConflictError
+func Conflict(err error, msg string) error {
+	if err != nil && errors.Is(err, db.ErrVersionConflict) {
+		return ConflictError{Msg: msg}
+	}
+	return err
+}
+
 type InternalError struct {
 	Msg string
 }
@@ -92,12 +114,13 @@ func (h HandleError) ErrorPrint(err error) {
 }
 
 func (h HandleError) DebugPrint(err string, v ...interface{}) {
-	h.logger.Debugf(err, v)
+	h.logger.Debugf(err, v...)
 }
 
 func (h HandleError) ErrorReturn(err error) error {
+	err = Conflict(err, constant.DataConflict.String())
 	if IsAccessNotAllowedUserNotFound(err) ||
-		IsNotFound(err) || IsInvalidData(err) {
+		IsNotFound(err) || IsInvalidData(err) || IsConflict(err) {
 		return err
 	}
 
@@ -1,7 +1,11 @@
 package mapper
 
+// MySQL error numbers, they only match the mysql driver
 const (
-	DuplicateEntryCode       = 1062
-	DataTruncateCode         = 1265
+	// Deprecated: use db.TranslateError and compare Kind with db.SQLErrDuplicateKey
+	DuplicateEntryCode = 1062
+	// Deprecated: use db.TranslateError and compare Kind with db.SQLErrInvalidValue
+	DataTruncateCode = 1265
+	// Deprecated: use db.TranslateError and compare Kind with db.SQLErrForeignKey
 	ForeignConstrainFailCode = 1452
 )
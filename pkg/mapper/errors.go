@@ -1,6 +1,7 @@
 package mapper
 
 import (
+	"base-be-golang/pkg/db"
 	localerror2 "base-be-golang/pkg/localerror"
 	"base-be-golang/pkg/localize"
 	"base-be-golang/pkg/middleware"
@@ -12,60 +13,50 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-sql-driver/mysql"
 )
 
+// TranslateSQLErr map database error of any DB_DRIVER (see db.TranslateError) to domain error
 // TODO: validation move to struct tags
-func (m Mapper) TranslateSQLErr(mySqlErr *mysql.MySQLError, methodName string) error {
-	switch mySqlErr.Number {
-	case DuplicateEntryCode:
-		re := regexp.MustCompile(`for key '([^']+)`)
-		msgs := re.FindStringSubmatch(mySqlErr.Message)
-		if len(msgs) > 1 {
-			switch msgs[1] {
-			//case "kassir_users.uk_kassir_users_username":
-			//	return localerror.InvalidDataError{Msg: ErrUserNameIsUsed.Error()}
-			default:
-				return mySqlErr
-			}
+func (m Mapper) TranslateSQLErr(err error, methodName string) error {
+	sqlErr, ok := db.TranslateError(err)
+	if !ok {
+		return err
+	}
+
+	switch sqlErr.Kind {
+	case db.SQLErrDuplicateKey:
+		switch sqlErr.Constraint {
+		//case "uk_kassir_users_username":
+		//	return localerror.InvalidDataError{Msg: ErrUserNameIsUsed.Error()}
+		default:
+			return err
 		}
-		return mySqlErr
-
-	case DataTruncateCode:
-		re := regexp.MustCompile(`for column '([^']+)`)
-		msgs := re.FindStringSubmatch(mySqlErr.Message)
-		if len(msgs) > 1 {
-			switch msgs[1] {
-			case "gender":
-				//return localerror.InvalidDataError{
-				//	Msg: ErrInvalidGender.Error(),
-				//}
-			default:
-				return mySqlErr
-			}
+
+	case db.SQLErrDataTooLong, db.SQLErrInvalidValue:
+		switch sqlErr.Column {
+		case "gender":
+			//return localerror.InvalidDataError{
+			//	Msg: ErrInvalidGender.Error(),
+			//}
+		default:
+			return err
 		}
-		return fmt.Errorf("%s: %w", methodName, mySqlErr)
-
-	case ForeignConstrainFailCode:
-		re := regexp.MustCompile("CONSTRAINT `([^`]+)")
-		msgs := re.FindStringSubmatch(mySqlErr.Message)
-		if len(msgs) > 1 {
-			switch msgs[1] {
-			//case "fk_kassir_branches":
-			//	return localerror.InvalidDataError{
-			//		Msg: ErrBranchNotFound.Error(),
-			//	}
-			default:
-				return mySqlErr
-			}
+		return fmt.Errorf("%s: %w", methodName, err)
+
+	case db.SQLErrForeignKey:
+		switch sqlErr.Constraint {
+		//case "fk_kassir_branches":
+		//	return localerror.InvalidDataError{
+		//		Msg: ErrBranchNotFound.Error(),
+		//	}
+		default:
+			return err
 		}
 
 	default:
-		return mySqlErr
+		return err
 	}
 
-	return mySqlErr
-
 }
 
 func (receiver Mapper) GetAuthDataFromContext(c *gin.Context) middleware.UserData {
@@ -103,6 +94,13 @@ func (m Mapper) NewResponse(c *gin.Context, res *payload.Response, err error) {
 			)
 			return
 		}
+		if m.IsConflictError(err) {
+			c.JSON(
+				http.StatusConflict,
+				payload.DefaultErrorInvalidDataWithMessage(m.localizer.GetLocalized(userData.Lang, err.Error())),
+			)
+			return
+		}
 		middleware.CaptureError(c, err)
 		fmt.Printf("ERROR: %s \n", err.Error())
 		c.JSON(
@@ -136,6 +134,11 @@ func (m Mapper) IsAccessControlError(err error) bool {
 	return false
 }
 
+func (m Mapper) IsConflictError(err error) bool {
+	var conflictError localerror2.ConflictError
+	return errors.As(err, &conflictError)
+}
+
 func (m Mapper) CompareSliceOfErr(errs []error, target error) bool {
 	for _, err := range errs {
 		if errors.Is(err, target) {
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"base-be-golang/pkg/tenant"
+	"base-be-golang/shared/payload"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultTenantHeader = "X-Tenant-ID"
+
+// TenantHeader header carrying the tenant before login, env TENANT_HEADER, default X-Tenant-ID
+func TenantHeader() string {
+	if header := os.Getenv("TENANT_HEADER"); header != "" {
+		return header
+	}
+	return defaultTenantHeader
+}
+
+/*
+Tenant put the tenant of the request header into the request context (see tenant.With).
+Request without the header stays single tenant, after login the tenant of the token is used
+and auth middleware reject a header of another tenant.
+*/
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(TenantHeader())
+		if id == "" {
+			c.Next()
+			return
+		}
+
+		if !tenant.Valid(id) {
+			c.JSON(http.StatusBadRequest, payload.DefaultErrorInvalidDataWithMessage("invalid tenant"))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.With(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -3,6 +3,7 @@
 package middleware
 
 import (
+	"base-be-golang/pkg/db"
 	"base-be-golang/shared/payload"
 	"encoding/json"
 	"fmt"
@@ -152,6 +153,21 @@ func (v Enigma) BindQueryToFilterAndValidate(c *gin.Context, payload interface{}
 	return v.validate(payload)
 }
 
+/*
+BindFilter parse filter[field][op] and sort query params by spec, see db.FilterSpec.
+Date without zone is read in the timezone of the session user.
+*/
+func (v Enigma) BindFilter(c *gin.Context, spec db.FilterSpec) (db.Filter, map[string][]string) {
+	var tz *time.Location
+	if dt, ok := c.Get(string(payload.AuthCodeContext)); ok {
+		if d, ok := dt.(payload.UserData); ok {
+			tz = d.Tz
+		}
+	}
+
+	return spec.Parse(c.Request.URL.Query(), tz)
+}
+
 func (v Enigma) queryToFilter(c *gin.Context, payload interface{}, isDive bool) error {
 	pVal, pType, vals, err := v.preparingReflection(payload, isDive)
 	if err != nil {
@@ -0,0 +1,64 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const VersionFormat = "20060102150405"
+
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var migrationTemplate = template.Must(template.New("migration").Parse(`package {{.Package}}
+
+import (
+	"base-be-golang/pkg/migration"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(migration.Migration{
+		Version: "{{.Version}}",
+		Name:    "{{.Name}}",
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`))
+
+/*
+Create write an empty migration file <version>_<name>.go to dir, the package in dir must have register
+(see internal/migrations). Name is snake case, e.g. create_articles.
+*/
+func Create(dir string, name string, now time.Time) (string, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return "", fmt.Errorf("migration name %q must be snake case", name)
+	}
+
+	version := now.UTC().Format(VersionFormat)
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.go", version, name))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	err = migrationTemplate.Execute(file, map[string]string{
+		"Package": strings.ReplaceAll(filepath.Base(filepath.Clean(dir)), "-", "_"),
+		"Version": version,
+		"Name":    name,
+	})
+
+	return path, err
+}
@@ -0,0 +1,189 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+/*
+Migration one versioned schema change. Version is a timestamp (20060102150405), so migrations of
+every module are applied in the order they were created. Up and Down run inside one transaction,
+MySQL commits DDL implicitly so keep one DDL statement per migration there.
+*/
+type Migration struct {
+	Version string
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// Source migrations shipped by one module, e.g. app or iam
+type Source struct {
+	Module     string
+	Migrations []Migration
+}
+
+// History applied migration, one row per version
+type History struct {
+	Version   string    `gorm:"column:version;primaryKey;type:varchar(14)"`
+	Module    string    `gorm:"column:module;type:varchar(50)"`
+	Name      string    `gorm:"column:name;type:varchar(255)"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (History) TableName() string {
+	return "schema_migrations"
+}
+
+type Status struct {
+	Version   string
+	Module    string
+	Name      string
+	AppliedAt *time.Time
+}
+
+type moduleMigration struct {
+	Migration
+	module string
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []moduleMigration
+}
+
+// NewMigrator reject duplicate version across modules
+func NewMigrator(db *gorm.DB, sources ...Source) (Migrator, error) {
+	var (
+		migrations []moduleMigration
+		versions   = map[string]string{}
+	)
+	for _, source := range sources {
+		for _, m := range source.Migrations {
+			if module, ok := versions[m.Version]; ok {
+				return Migrator{}, fmt.Errorf("migration %s of %s has the same version as %s", m.Version, source.Module, module)
+			}
+			if m.Up == nil || m.Down == nil {
+				return Migrator{}, fmt.Errorf("migration %s of %s needs both up and down", m.Version, source.Module)
+			}
+			versions[m.Version] = source.Module
+			migrations = append(migrations, moduleMigration{Migration: m, module: source.Module})
+		}
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+func (m Migrator) applied(ctx context.Context) (map[string]History, error) {
+	conn := m.db.WithContext(ctx)
+	if err := conn.AutoMigrate(&History{}); err != nil {
+		return nil, err
+	}
+
+	var histories []History
+	if err := conn.Find(&histories).Error; err != nil {
+		return nil, err
+	}
+
+	var result = make(map[string]History, len(histories))
+	for _, h := range histories {
+		result[h.Version] = h
+	}
+	return result, nil
+}
+
+// Up apply every pending migration, stop at the first failure
+func (m Migrator) Up(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Status
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+
+		history := History{
+			Version:   mg.Version,
+			Module:    mg.module,
+			Name:      mg.Name,
+			AppliedAt: time.Now().UTC(),
+		}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := mg.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&history).Error
+		})
+		if err != nil {
+			return result, fmt.Errorf("migrate up %s_%s: %w", mg.Version, mg.Name, err)
+		}
+
+		result = append(result, Status{Version: mg.Version, Module: mg.module, Name: mg.Name, AppliedAt: &history.AppliedAt})
+	}
+
+	return result, nil
+}
+
+// Down revert the last step applied migrations, newest first
+func (m Migrator) Down(ctx context.Context, step int) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Status
+	for i := len(m.migrations) - 1; i >= 0 && len(result) < step; i-- {
+		mg := m.migrations[i]
+		history, ok := applied[mg.Version]
+		if !ok {
+			continue
+		}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := mg.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&history).Error
+		})
+		if err != nil {
+			return result, fmt.Errorf("migrate down %s_%s: %w", mg.Version, mg.Name, err)
+		}
+
+		result = append(result, Status{Version: mg.Version, Module: mg.module, Name: mg.Name})
+	}
+
+	return result, nil
+}
+
+// Status every known migration with its applied time, nil when pending
+func (m Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result = make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		status := Status{Version: mg.Version, Module: mg.module, Name: mg.Name}
+		if history, ok := applied[mg.Version]; ok {
+			status.AppliedAt = &history.AppliedAt
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
@@ -1,6 +1,7 @@
 package miniostorage
 
 import (
+	"base-be-golang/pkg/tenant"
 	"bytes"
 	"context"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"io"
 )
 
+// StorageMinio object is stored under "<tenant>/" prefix when ctx has tenant, file name given to caller stays the same
 type StorageMinio struct {
 	client *minio.Client
 	bucket string
@@ -34,7 +36,7 @@ func NewConnection(conn Conn) StorageMinio {
 }
 
 func (st StorageMinio) GetFile(ctx context.Context, fileName string) (*bytes.Buffer, error) {
-	obj, err := st.client.GetObject(ctx, st.bucket, fileName, minio.GetObjectOptions{})
+	obj, err := st.client.GetObject(ctx, st.bucket, tenant.Path(ctx, fileName), minio.GetObjectOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +51,7 @@ func (st StorageMinio) GetFile(ctx context.Context, fileName string) (*bytes.Buf
 }
 
 func (st StorageMinio) StoreFile(ctx context.Context, fileName string, file io.Reader, fileSize int64) (minio.UploadInfo, error) {
-	uploadInfo, err := st.client.PutObject(ctx, st.bucket, fileName, file, fileSize, minio.PutObjectOptions{})
+	uploadInfo, err := st.client.PutObject(ctx, st.bucket, tenant.Path(ctx, fileName), file, fileSize, minio.PutObjectOptions{})
 	if err != nil {
 		return minio.UploadInfo{}, err
 	}
@@ -58,5 +60,5 @@ func (st StorageMinio) StoreFile(ctx context.Context, fileName string, file io.R
 }
 
 func (st StorageMinio) DeleteFile(ctx context.Context, fileName string) error {
-	return st.client.RemoveObject(ctx, st.bucket, fileName, minio.RemoveObjectOptions{})
+	return st.client.RemoveObject(ctx, st.bucket, tenant.Path(ctx, fileName), minio.RemoveObjectOptions{})
 }
@@ -0,0 +1,44 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+/*
+Fulltext MySQL FULLTEXT engine, every word must match as prefix (boolean mode "+word*") and the
+relevance is the MATCH score. The index is maintained by MySQL, words shorter than
+innodb_ft_min_token_size (default 3) and stopwords are not indexed.
+*/
+type Fulltext struct{}
+
+func (f Fulltext) Match(ctx context.Context, model Indexable, text string) (Match, error) {
+	words := tokenize(text)
+	if len(words) == 0 {
+		return noMatch(), nil
+	}
+
+	var terms = make([]string, len(words))
+	for i, word := range words {
+		terms[i] = "+" + word + "*"
+	}
+
+	var (
+		columns      = model.SearchColumns()
+		placeholders = make([]string, len(columns))
+		vars         = make([]interface{}, 0, len(columns)+1)
+	)
+	for i, col := range columns {
+		placeholders[i] = "?"
+		vars = append(vars, clause.Column{Table: model.TableName(), Name: col})
+	}
+	vars = append(vars, strings.Join(terms, " "))
+
+	exp := clause.Expr{
+		SQL:  "MATCH(" + strings.Join(placeholders, ", ") + ") AGAINST (? IN BOOLEAN MODE)",
+		Vars: vars,
+	}
+	return Match{Where: exp, Relevance: exp}, nil
+}
@@ -0,0 +1,339 @@
+package search
+
+import (
+	"base-be-golang/pkg/db"
+	"base-be-golang/pkg/tenant"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultLocalRefresh = 5 * time.Minute
+	maxLocalRanked      = 1000
+
+	// BM25 term frequency saturation and length normalization
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// weight of a word that is only a prefix of the indexed term
+	prefixWeight = 0.5
+)
+
+/*
+Local in-process inverted index, fallback for driver without FULLTEXT and for local development.
+Every word must match as prefix, relevance is BM25 (exact word weigh more than prefix).
+
+The index of a table is loaded on the first search and rebuilt after a write to the table made
+through the registered connection (see Register), or after env SEARCH_LOCAL_REFRESH_MINUTES (default 5)
+for write of another instance. It holds every row in memory, use it for table of thousands of rows, not millions.
+Where keeps every hit, so count and pagination are exact, Relevance scores the 1000 best and the rest get 0.
+*/
+type Local struct {
+	db      *gorm.DB
+	refresh time.Duration
+
+	mu      sync.Mutex
+	indexes map[string]*localIndex
+	version map[string]uint64
+	flight  singleflight.Group
+}
+
+type localIndex struct {
+	version    uint64
+	builtAt    time.Time
+	primaryKey string
+	hasTenant  bool
+	// terms sorted, for prefix lookup
+	terms    []string
+	postings map[string]map[interface{}]int
+	length   map[interface{}]int
+	tenants  map[interface{}]string
+	avgLen   float64
+}
+
+func NewLocal(dbConn *gorm.DB) *Local {
+	refresh := defaultLocalRefresh
+	if minutes, err := strconv.Atoi(os.Getenv("SEARCH_LOCAL_REFRESH_MINUTES")); err == nil && minutes > 0 {
+		refresh = time.Duration(minutes) * time.Minute
+	}
+	return &Local{
+		db:      dbConn,
+		refresh: refresh,
+		indexes: map[string]*localIndex{},
+		version: map[string]uint64{},
+	}
+}
+
+func (l *Local) Match(ctx context.Context, model Indexable, text string) (Match, error) {
+	words := tokenize(text)
+	if len(words) == 0 {
+		return noMatch(), nil
+	}
+
+	index, err := l.index(ctx, model)
+	if err != nil {
+		return Match{}, err
+	}
+
+	tenantID, scoped := tenant.FromContext(ctx)
+	scores := index.score(words, func(id interface{}) bool {
+		return !scoped || !index.hasTenant || index.tenants[id] == tenantID
+	})
+	if len(scores) == 0 {
+		return noMatch(), nil
+	}
+
+	var ids = make([]interface{}, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return fmt.Sprint(ids[i]) < fmt.Sprint(ids[j])
+	})
+	ranked := ids
+	if len(ranked) > maxLocalRanked {
+		ranked = ranked[:maxLocalRanked]
+	}
+
+	col := clause.Column{Table: model.TableName(), Name: index.primaryKey}
+	var (
+		relevance = strings.Builder{}
+		vars      = []interface{}{col}
+	)
+	relevance.WriteString("CASE ?")
+	for _, id := range ranked {
+		relevance.WriteString(" WHEN ? THEN ?")
+		vars = append(vars, id, math.Round(scores[id]*10000)/10000)
+	}
+	relevance.WriteString(" ELSE 0 END")
+
+	return Match{
+		Where:     clause.IN{Column: col, Values: ids},
+		Relevance: clause.Expr{SQL: relevance.String(), Vars: vars},
+	}, nil
+}
+
+// index of the table, rebuilt when a write was seen or refresh passed
+func (l *Local) index(ctx context.Context, model Indexable) (*localIndex, error) {
+	table := model.TableName()
+
+	l.mu.Lock()
+	index, version := l.indexes[table], l.version[table]
+	l.mu.Unlock()
+	if index != nil && index.version == version && time.Since(index.builtAt) < l.refresh {
+		return index, nil
+	}
+
+	built, err, _ := l.flight.Do(table, func() (interface{}, error) {
+		index, err := l.build(ctx, model, version)
+		if err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		l.indexes[table] = index
+		l.mu.Unlock()
+		return index, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return built.(*localIndex), nil
+}
+
+// build read the search columns of every row of every tenant, soft deleted rows are left out
+func (l *Local) build(ctx context.Context, model Indexable, version uint64) (*localIndex, error) {
+	stmt := &gorm.Statement{DB: l.db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("search: %s has no primary key", model.TableName())
+	}
+
+	index := &localIndex{
+		version:    version,
+		builtAt:    time.Now(),
+		primaryKey: stmt.Schema.PrioritizedPrimaryField.DBName,
+		hasTenant:  stmt.Schema.LookUpField("tenant_id") != nil,
+		postings:   map[string]map[interface{}]int{},
+		length:     map[interface{}]int{},
+		tenants:    map[interface{}]string{},
+	}
+
+	columns := append([]string{index.primaryKey}, model.SearchColumns()...)
+	if index.hasTenant {
+		columns = append(columns, "tenant_id")
+	}
+
+	var rows []map[string]interface{}
+	err := l.db.
+		Session(&gorm.Session{NewDB: true, Context: tenant.Without(context.WithoutCancel(ctx))}).
+		Model(reflect.New(reflect.TypeOf(model)).Interface()).
+		Select(columns).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	for _, row := range rows {
+		id := row[index.primaryKey]
+		if raw, ok := id.([]byte); ok {
+			id = string(raw)
+		}
+
+		for _, col := range model.SearchColumns() {
+			for _, word := range tokenize(text(row[col])) {
+				if index.postings[word] == nil {
+					index.postings[word] = map[interface{}]int{}
+					index.terms = append(index.terms, word)
+				}
+				index.postings[word][id]++
+				index.length[id]++
+				total++
+			}
+		}
+		if index.hasTenant {
+			index.tenants[id] = text(row["tenant_id"])
+		}
+	}
+	slices.Sort(index.terms)
+	if len(index.length) > 0 {
+		index.avgLen = float64(total) / float64(len(index.length))
+	}
+
+	return index, nil
+}
+
+// score BM25 of doc that match every word as prefix of a term, visible filter the doc of other tenant
+func (index *localIndex) score(words []string, visible func(id interface{}) bool) map[interface{}]float64 {
+	var scores map[interface{}]float64
+	for _, word := range words {
+		var freq = map[interface{}]float64{}
+		for i := sort.SearchStrings(index.terms, word); i < len(index.terms) && strings.HasPrefix(index.terms[i], word); i++ {
+			weight := prefixWeight
+			if index.terms[i] == word {
+				weight = 1
+			}
+			for id, count := range index.postings[index.terms[i]] {
+				if visible(id) {
+					freq[id] += weight * float64(count)
+				}
+			}
+		}
+
+		total, found := float64(len(index.length)), float64(len(freq))
+		idf := math.Log(1 + (total-found+0.5)/(found+0.5))
+
+		var next = make(map[interface{}]float64, len(freq))
+		for id, f := range freq {
+			if _, ok := scores[id]; scores != nil && !ok {
+				continue
+			}
+			norm := f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*float64(index.length[id])/index.avgLen))
+			next[id] = scores[id] + idf*norm
+		}
+		scores = next
+	}
+	return scores
+}
+
+func text(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(val)
+}
+
+/*
+================ WRITE TRACKING ==============
+*/
+
+// track invalidate the index of Indexable entity written through dbConn
+func (l *Local) track(dbConn *gorm.DB) error {
+	err := dbConn.Callback().Create().After("gorm:create").Register("search:local_create", l.touch)
+	if err != nil {
+		return err
+	}
+	err = dbConn.Callback().Update().After("gorm:update").Register("search:local_update", l.touch)
+	if err != nil {
+		return err
+	}
+	return dbConn.Callback().Delete().After("gorm:delete").Register("search:local_delete", l.touch)
+}
+
+// indexableOf find the Indexable of model, bulk write carry *[]T or []T so the element type is used
+func indexableOf(model any) (Indexable, bool) {
+	if indexable, ok := model.(Indexable); ok {
+		return indexable, true
+	}
+	t := reflect.TypeOf(model)
+	if t == nil {
+		return nil, false
+	}
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	indexable, ok := reflect.New(t).Interface().(Indexable)
+	return indexable, ok
+}
+
+func (l *Local) touch(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+	model, ok := indexableOf(tx.Statement.Model)
+	if !ok {
+		return
+	}
+
+	// update of unrelated column (e.g. last_active) keep the index
+	if set, ok := tx.Statement.Clauses["SET"].Expression.(clause.Set); ok {
+		relevant := false
+		for _, assignment := range set {
+			name := assignment.Column.Name
+			if slices.Contains(model.SearchColumns(), name) || name == "deleted_at" || name == "tenant_id" {
+				relevant = true
+				break
+			}
+		}
+		if !relevant {
+			return
+		}
+	}
+
+	table := tx.Statement.Schema.Table
+	l.invalidate(table)
+	if ctx := tx.Statement.Context; ctx != nil {
+		// inside UnitOfWork the index may be rebuilt before the write is committed
+		db.AfterCommit(ctx, func() {
+			l.invalidate(table)
+		})
+	}
+}
+
+func (l *Local) invalidate(table string) {
+	l.mu.Lock()
+	l.version[table]++
+	l.mu.Unlock()
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"os"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	EngineFulltext = "fulltext"
+	EngineLocal    = "local"
+
+	pluginName = "search"
+)
+
+/*
+Indexable entity opt-in to full-text search, SearchColumns are matched against the search text.
+With EngineFulltext the columns need a FULLTEXT index in the same order.
+
+	func (a Article) SearchColumns() []string {
+		return []string{"title", "body"}
+	}
+*/
+type Indexable interface {
+	schema.Tabler
+	SearchColumns() []string
+}
+
+/*
+Match of one search on the table of the model. Where keep only the hits, Relevance is the score
+of the row, higher is more relevant. Both are qualified with the table name, so they work inside join and union.
+
+	match, err := engine.Match(ctx, domain.Article{}, "golang tips")
+	err = conn.Model(&domain.Article{}).
+		Select("articles.*, ? as relevance", match.Relevance).
+		Where(match.Where).
+		Order("relevance desc").
+		Find(&result).Error
+*/
+type Match struct {
+	Where     clause.Expression
+	Relevance clause.Expression
+}
+
+type Engine interface {
+	Match(ctx context.Context, model Indexable, text string) (Match, error)
+}
+
+/*
+New choose the engine by env SEARCH_ENGINE (fulltext | local),
+default fulltext on mysql and local on the other drivers.
+*/
+func New(dbConn *gorm.DB) Engine {
+	engine := os.Getenv("SEARCH_ENGINE")
+	if engine == "" {
+		engine = EngineLocal
+		if dbConn.Dialector.Name() == "mysql" {
+			engine = EngineFulltext
+		}
+	}
+
+	if engine == EngineFulltext {
+		return Fulltext{}
+	}
+	return NewLocal(dbConn)
+}
+
+// Register attach the engine of New to the connection, the local index then follow writes made through it
+func Register(dbConn *gorm.DB) error {
+	return dbConn.Use(plugin{Engine: New(dbConn)})
+}
+
+// From return the engine registered to the connection, or a new one when Register was not called
+func From(dbConn *gorm.DB) Engine {
+	if p, ok := dbConn.Config.Plugins[pluginName].(plugin); ok {
+		return p.Engine
+	}
+	return New(dbConn)
+}
+
+type plugin struct {
+	Engine
+}
+
+func (p plugin) Name() string {
+	return pluginName
+}
+
+func (p plugin) Initialize(dbConn *gorm.DB) error {
+	if local, ok := p.Engine.(*Local); ok {
+		return local.track(dbConn)
+	}
+	return nil
+}
+
+// tokenize lowercase words of text, split on everything that is not a letter or digit
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func noMatch() Match {
+	return Match{
+		Where:     clause.Expr{SQL: "1 = 0"},
+		Relevance: clause.Expr{SQL: "0"},
+	}
+}
@@ -0,0 +1,71 @@
+package seed
+
+import (
+	"base-be-golang/pkg/db"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm/schema"
+)
+
+/*
+LoadFixture insert rows of a YAML (.yml, .yaml) or JSON (.json) file through repo and return them.
+Keys follow the entity json tags, so the same file shape as the API response works. Set id in the
+fixture to refer to the row from other fixtures.
+
+	# testdata/master_roles.yaml
+	- id: 1
+	  name: ADMIN
+	  label: Administrator
+
+	roles, err := seed.LoadFixture(ctx, db.NewGenericeRepo(dbConn, domain.MasterRole{}), "testdata/master_roles.yaml")
+*/
+func LoadFixture[T schema.Tabler](ctx context.Context, repo db.GenericRepository[T], path string) ([]T, error) {
+	return LoadFixtureFS(ctx, repo, os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// LoadFixtureFS same as LoadFixture, read from fsys (e.g. embed.FS)
+func LoadFixtureFS[T schema.Tabler](ctx context.Context, repo db.GenericRepository[T], fsys fs.FS, name string) ([]T, error) {
+	raw, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := decodeFixture[T](name, raw)
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", name, err)
+	}
+	if len(rows) == 0 {
+		return rows, nil
+	}
+
+	return repo.BulkStore(ctx, rows)
+}
+
+// decodeFixture yaml is converted to json first, so entity json tags apply to both format
+func decodeFixture[T any](name string, raw []byte) ([]T, error) {
+	switch filepath.Ext(name) {
+	case ".yml", ".yaml":
+		var doc interface{}
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return nil, err
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		raw = converted
+	case ".json":
+	default:
+		return nil, fmt.Errorf("unsupported fixture format %s", filepath.Ext(name))
+	}
+
+	var rows []T
+	err := json.Unmarshal(raw, &rows)
+	return rows, err
+}
@@ -0,0 +1,128 @@
+package seed
+
+import (
+	"base-be-golang/pkg/db"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+)
+
+const Actor = "seeder"
+
+// Skip returned by Run when the seeder has nothing to do (e.g. its env is unset), the run goes on
+// with err as the reason, unless the seeder is named in only
+func Skip(err error) error {
+	return skipError{err: err}
+}
+
+type skipError struct {
+	err error
+}
+
+func (e skipError) Error() string { return e.err.Error() }
+func (e skipError) Unwrap() error { return e.err }
+
+/*
+Seeder insert the data a fresh install needs. Run must be idempotent (check before insert),
+it is executed on every `cmd/seed` run. Environments limit the seeder to ENVIRONMENT values, empty means every environment.
+Repositories created from dbConn join the seeder transaction through ctx.
+*/
+type Seeder struct {
+	Name         string
+	Environments []string
+	Run          func(ctx context.Context, dbConn *gorm.DB) error
+}
+
+// Source seeders of one module, run in the given order
+type Source struct {
+	Module  string
+	Seeders []Seeder
+}
+
+type Result struct {
+	Module  string
+	Name    string
+	Skipped bool
+	Reason  string
+}
+
+type Runner struct {
+	db      *gorm.DB
+	uow     db.UnitOfWork
+	sources []Source
+}
+
+func NewRunner(dbConn *gorm.DB, sources ...Source) Runner {
+	return Runner{
+		db:      dbConn,
+		uow:     db.NewUnitOfWork(dbConn),
+		sources: sources,
+	}
+}
+
+/*
+Run every seeder allowed in environment, each in its own transaction. Only limit the run to the given
+seeder names ("module.name" or "name"), empty means all. Stop at the first failure,
+seeder returning Skip is only recorded as skipped when it is not named in only.
+*/
+func (r Runner) Run(ctx context.Context, environment string, only ...string) ([]Result, error) {
+	ctx = db.WithActor(ctx, Actor)
+
+	var result []Result
+	for _, source := range r.sources {
+		for _, s := range source.Seeders {
+			named := slices.Contains(only, s.Name) || slices.Contains(only, source.Module+"."+s.Name)
+			if len(only) > 0 && !named {
+				continue
+			}
+
+			res := Result{Module: source.Module, Name: s.Name}
+			if len(s.Environments) > 0 && !slices.Contains(s.Environments, environment) {
+				res.Skipped = true
+				res.Reason = "environment"
+				result = append(result, res)
+				continue
+			}
+
+			err := r.uow.WithinTransaction(ctx, func(ctx context.Context) error {
+				return s.Run(ctx, r.db)
+			})
+			var skip skipError
+			if errors.As(err, &skip) && !named {
+				res.Skipped = true
+				res.Reason = err.Error()
+				result = append(result, res)
+				continue
+			}
+			if err != nil {
+				return result, fmt.Errorf("seed %s.%s: %w", source.Module, s.Name, err)
+			}
+			result = append(result, res)
+		}
+	}
+
+	return result, nil
+}
+
+// RequiredEnv read env used by seeder, error tell which key is missing
+func RequiredEnv(keys ...string) (map[string]string, error) {
+	var (
+		values  = make(map[string]string, len(keys))
+		missing []string
+	)
+	for _, key := range keys {
+		values[key] = os.Getenv(key)
+		if values[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return values, fmt.Errorf("missing env %s", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
@@ -0,0 +1,72 @@
+package tenant
+
+import (
+	"base-be-golang/shared/payload"
+	"context"
+	"regexp"
+)
+
+type (
+	tenantCtxKey  struct{}
+	withoutCtxKey struct{}
+)
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Valid tenant id is 1-64 of letter, digit, - and _, so it is safe in cache key and object path
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// With set the tenant of ctx, e.g. background job of one tenant
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, id)
+}
+
+// Without lift tenant scoping inside ctx, for cross tenant job or super admin, use carefully
+func Without(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutCtxKey{}, true)
+}
+
+/*
+FromContext resolve tenant in order:
+  - nothing when Without is used
+  - tenant set by With (tenant middleware, session)
+  - tenantId of the user attached by auth middleware
+
+False means single tenant operation, repository and cache are not scoped.
+*/
+func FromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	if without, _ := ctx.Value(withoutCtxKey{}).(bool); without {
+		return "", false
+	}
+
+	if id, ok := ctx.Value(tenantCtxKey{}).(string); ok && id != "" {
+		return id, true
+	}
+
+	if user, ok := ctx.Value(payload.AuthCodeContext).(payload.UserData); ok && user.TenantID != "" {
+		return user.TenantID, true
+	}
+
+	return "", false
+}
+
+// Key namespace cache key, "tenant:<id>:<key>", key is unchanged without tenant
+func Key(ctx context.Context, key string) string {
+	if id, ok := FromContext(ctx); ok {
+		return "tenant:" + id + ":" + key
+	}
+	return key
+}
+
+// Path namespace object name of storage, "<id>/<name>", name is unchanged without tenant
+func Path(ctx context.Context, name string) string {
+	if id, ok := FromContext(ctx); ok {
+		return id + "/" + name
+	}
+	return name
+}
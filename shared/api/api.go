@@ -1,9 +1,11 @@
 package api
 
 import (
+	"base-be-golang/internal/constant"
 	"base-be-golang/pkg/cache"
 	"base-be-golang/pkg/logger"
 	"base-be-golang/pkg/miniostorage"
+	"base-be-golang/shared/base"
 	"os"
 
 	"github.com/gin-gonic/gin"
@@ -23,11 +25,23 @@ type Router interface {
 	Route(handler *gin.RouterGroup)
 }
 
+// WellKnownRouter optional, for router that serve under /.well-known (RFC 8615) outside the api version
+type WellKnownRouter interface {
+	RouteWellKnown(handler *gin.RouterGroup)
+}
+
 func (a *Api) Start() error {
 	root := a.server.Group("/api/v1")
+	root.GET("/health", a.health)
+	security := base.NewAuth(a.db, a.cache)
+	root.GET("/health/details", security.Validate(), security.Authorize(constant.RoleIsAdmin), a.healthDetails)
 
+	wellKnown := a.server.Group("/.well-known")
 	for _, router := range a.routers {
 		router.Route(root)
+		if r, ok := router.(WellKnownRouter); ok {
+			r.RouteWellKnown(wellKnown)
+		}
 	}
 
 	port := os.Getenv("APP_PORT")
@@ -3,8 +3,10 @@ package api
 import (
 	"base-be-golang/pkg/cache"
 	"base-be-golang/pkg/db"
+	"base-be-golang/pkg/logger"
 	"base-be-golang/pkg/middleware"
 	"base-be-golang/pkg/miniostorage"
+	"base-be-golang/pkg/search"
 	"fmt"
 	"os"
 	"time"
@@ -33,6 +35,7 @@ func Default() *Api {
 	server := gin.Default()
 
 	server.Use(middleware.AllowCORS())
+	server.Use(middleware.Tenant())
 
 	// Add Sentry middleware with enhanced configuration
 	server.Use(sentrygin.New(sentrygin.Options{
@@ -44,11 +47,23 @@ func Default() *Api {
 	// Add custom Sentry middleware for request enrichment
 	server.Use(middleware.SentryMiddleware())
 
+	reZero := logger.DefaultLogger()
+
 	dbConn, err := db.Default()
 	if err != nil {
 		panic(fmt.Sprintf("panic at db connection: %s", err.Error()))
 	}
 
+	err = dbConn.Use(db.NewInstrument(&reZero))
+	if err != nil {
+		panic(fmt.Sprintf("panic at db instrument: %s", err.Error()))
+	}
+
+	err = search.Register(dbConn)
+	if err != nil {
+		panic(fmt.Sprintf("panic at search engine: %s", err.Error()))
+	}
+
 	dbCache := cache.Default()
 
 	minioStr := miniostorage.NewConnection(miniostorage.Conn{
@@ -63,6 +78,7 @@ func Default() *Api {
 		cache:    dbCache,
 		minioStr: minioStr,
 		db:       dbConn,
+		reZero:   &reZero,
 	}
 
 	return &api
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"base-be-golang/pkg/db"
+	"base-be-golang/shared/payload"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type healthStatus struct {
+	Status string `json:"status"`
+}
+
+// health probe of load balancer and orchestrator, 503 when the database does not answer the ping. Public, status only
+func (a *Api) health(c *gin.Context) {
+	health := db.CheckHealth(c.Request.Context(), a.db)
+	if health.Status != db.HealthUp {
+		c.JSON(http.StatusServiceUnavailable, &payload.ErrorResponse{
+			ResponseMeta: payload.ResponseMeta{
+				MessageTitle: "Oops, something went wrong.",
+				Message:      "Service unavailable",
+			},
+			Data: healthStatus{Status: health.Status},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, payload.NewSuccessResponseNoMsg(healthStatus{Status: health.Status}))
+}
+
+// healthDetails health with the ping error, pool usage and query stats, admin only
+func (a *Api) healthDetails(c *gin.Context) {
+	health := db.CheckHealth(c.Request.Context(), a.db)
+	if health.Status != db.HealthUp {
+		response := payload.DefaultErrorResponseWithMessage("Service unavailable", errors.New(health.Error))
+		response.Data = health
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, payload.NewSuccessResponseNoMsg(health))
+}
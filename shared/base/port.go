@@ -4,6 +4,7 @@ import (
 	"base-be-golang/pkg/cache"
 	"base-be-golang/pkg/clock"
 	"base-be-golang/pkg/davinci"
+	"base-be-golang/pkg/db"
 	"base-be-golang/pkg/environment"
 	"base-be-golang/pkg/localerror"
 	"base-be-golang/pkg/logger"
@@ -29,6 +30,7 @@ type Port struct {
 	Davinci    Generator
 	Mailing    Mailing
 	Clock      Clock
+	UnitOfWork UnitOfWork
 }
 
 func NewPort(dbConn *gorm.DB, dbCache cache.DbClient, zero *logger.ReZero) Port {
@@ -40,6 +42,7 @@ func NewPort(dbConn *gorm.DB, dbCache cache.DbClient, zero *logger.ReZero) Port
 		Davinci:    davinci.DefaultDavinci(),
 		Mailing:    mailing.NewConfig(),
 		Clock:      clock.Default(),
+		UnitOfWork: db.NewUnitOfWork(dbConn),
 	}
 }
 
@@ -52,6 +55,10 @@ type Security interface {
 	GetSessionLogin(ctx context.Context, sessionData *payload.SessionDataUser) error
 }
 
+type UnitOfWork interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type StorageService interface {
 	GetFile(ctx context.Context, fileName string) (*bytes.Buffer, error)
 	StoreFile(ctx context.Context, fileName string, file io.Reader, fileSize int64) (string, error)
@@ -67,8 +74,13 @@ type ErrHandler interface {
 
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
+	GetDel(ctx context.Context, key string) (string, error)
 	Delete(ctx context.Context, keys ...string) error
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetXX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	SAdd(ctx context.Context, key string, expiration time.Duration, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SRem(ctx context.Context, key string, members ...string) error
 }
 
 type Mailing interface {
@@ -91,6 +103,10 @@ type Generator interface {
 	GenerateHashValue(session string, id string, i int) (string, error)
 	DecryptMessage(key []byte, data string) (string, error)
 	EncryptMessage(key, data []byte) (string, error)
+	HashAndSalt(pwd []byte) (string, error)
+	ComparePassword(hashedPwd string, pwd []byte) (bool, error)
+	IsPasswordHash(stored string) bool
+	NeedsRehash(hashedPwd string) bool
 	GenerateOTPCode(
 		secret string,
 		counter uint64,
@@ -136,6 +152,25 @@ func (uc Port) GenerateEmailBodyVerifyOTP(
 	return outWriter.String(), nil
 }
 
+func (uc Port) GenerateEmailBodyResetPassword(
+	ctx context.Context,
+	payload payload.EmailBodyResetPasswordPayload,
+) (string, error) {
+	htmlPath := "./resource/mailing/reset-password-email.html"
+	tmpl, err := template.ParseFiles(htmlPath)
+	if err != nil {
+		return "", err
+	}
+	outWriter := bytes.Buffer{}
+
+	err = tmpl.Execute(&outWriter, payload)
+	if err != nil {
+		return "", err
+	}
+
+	return outWriter.String(), nil
+}
+
 // ======================== BASE CONTROLLER ====================
 
 type BaseController struct {
@@ -161,6 +196,7 @@ type Validator interface {
 	BindQueryToFilterAndValidate(c *gin.Context, payload interface{}) map[string][]string
 	BindAndValidate(c *gin.Context, payload any) map[string][]string
 	BindQueryToFilter(c *gin.Context, payload interface{}) error
+	BindFilter(c *gin.Context, spec db.FilterSpec) (db.Filter, map[string][]string)
 }
 
 type Idempotent interface {
@@ -6,12 +6,14 @@ import (
 )
 
 type UserData struct {
-	UserId   string         `json:"userId"`
-	Lang     string         `json:"lang"`
-	Timezone string         `json:"timezone"`
-	Tz       *time.Location `json:"tz"`
-	Email    string         `json:"email"`
-	RoleName string         `json:"roleName"`
+	UserId    string         `json:"userId"`
+	Lang      string         `json:"lang"`
+	Timezone  string         `json:"timezone"`
+	Tz        *time.Location `json:"tz"`
+	Email     string         `json:"email"`
+	RoleName  string         `json:"roleName"`
+	TenantID  string         `json:"tenantId"`
+	SessionID string         `json:"sessionId"`
 }
 
 func (authData *UserData) LoadFromMap(m map[string]interface{}) error {
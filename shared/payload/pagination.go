@@ -26,6 +26,31 @@ func NewPagination[T any](data []T, total int, perPage int, page int) Pagination
 	}
 }
 
+type CursorPagination struct {
+	PerPage        int    `json:"perPage"`
+	NextCursor     string `json:"nextCursor"`
+	PreviousCursor string `json:"previousCursor"`
+}
+
+type CursorPaginationResponse[T any] struct {
+	Data       []T              `json:"data"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+func NewCursorPagination[T any](data []T, perPage int, next string, previous string) CursorPaginationResponse[T] {
+	if data == nil {
+		data = make([]T, 0)
+	}
+	return CursorPaginationResponse[T]{
+		Data: data,
+		Pagination: CursorPagination{
+			PerPage:        perPage,
+			NextCursor:     next,
+			PreviousCursor: previous,
+		},
+	}
+}
+
 type SummaryStatus struct {
 	Label string `json:"label"`
 	Type  string `json:"type"`
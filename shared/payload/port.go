@@ -5,3 +5,9 @@ type EmailBodyVerifyOTPPayload struct {
 	OTPs       []string `json:"otps"`
 	VerifyPage string   `json:"verifyPage"`
 }
+
+type EmailBodyResetPasswordPayload struct {
+	Name             string `json:"name"`
+	ResetPage        string `json:"resetPage"`
+	ExpiresInMinutes int    `json:"expiresInMinutes"`
+}
@@ -29,6 +29,18 @@ func (l *GetListQueryNoPeriod) SetIfEmpty() {
 
 }
 
+type GetListCursorQuery struct {
+	PerPage int    `bindQuery:"dataType=integer" json:"perPage"`
+	Cursor  string `json:"cursor"`
+	Search  string `json:"search"`
+}
+
+func (l *GetListCursorQuery) SetIfEmpty() {
+	if l.PerPage == 0 {
+		l.PerPage = 15
+	}
+}
+
 type GetListQuery struct {
 	PerPage      int          `json:"perPage"`
 	Page         int          `json:"page"`